@@ -0,0 +1,79 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/game"
+)
+
+// runFriendsCommand gofarm friends list|export: 查看或导出好友档案
+func runFriendsCommand(args []string) {
+	usage := "用法: gofarm friends list|export [--gid <账号GID>] [--inactive] [--days <天数>] [--csv|--json] [--out <文件>]"
+	if len(args) == 0 || (args[0] != "list" && args[0] != "export") {
+		fmt.Println(usage)
+		os.Exit(1)
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("friends", flag.ExitOnError)
+	gid := fs.Int64("gid", 0, "账号GID (只有一个账号的档案时可省略)")
+	inactiveOnly := fs.Bool("inactive", false, "只看不活跃的好友")
+	days := fs.Int("days", config.Current.FriendInactiveDays, "超过该天数没有等级或作物变化视为不活跃")
+	asCSV := fs.Bool("csv", false, "导出为CSV")
+	asJSON := fs.Bool("json", false, "导出为JSON (含等级/金币历史)")
+	out := fs.String("out", "", "导出文件 (默认输出到终端)")
+	fs.Parse(args[1:])
+
+	profiles, err := game.LoadFriendDirectory(*gid)
+	if err != nil {
+		fmt.Printf("读取好友档案失败: %v\n", err)
+		os.Exit(1)
+	}
+	if *inactiveOnly {
+		now := time.Now().Unix()
+		filtered := profiles[:0]
+		for _, p := range profiles {
+			if p.Inactive(*days, now) {
+				filtered = append(filtered, p)
+			}
+		}
+		profiles = filtered
+	}
+
+	if action == "list" {
+		game.PrintFriendDirectory(profiles, *days)
+		return
+	}
+
+	if *asCSV == *asJSON {
+		fmt.Println("请指定 --csv 或 --json 之一")
+		os.Exit(1)
+	}
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Printf("创建 %s 失败: %v\n", *out, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		w = f
+	}
+	if *asCSV {
+		err = game.ExportFriendDirectoryCSV(w, profiles, *days)
+	} else {
+		err = game.ExportFriendDirectoryJSON(w, profiles)
+	}
+	if err != nil {
+		fmt.Printf("导出失败: %v\n", err)
+		os.Exit(1)
+	}
+	if *out != "" {
+		fmt.Printf("已导出 %d 位好友到 %s\n", len(profiles), *out)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"gofarm/internal/game"
+)
+
+// parseJournalDate 解析日期: "2006-01-02" / today / yesterday
+func parseJournalDate(s string, now time.Time) (string, error) {
+	switch s {
+	case "":
+		return "", nil
+	case "today":
+		return now.Format("2006-01-02"), nil
+	case "yesterday":
+		return now.AddDate(0, 0, -1).Format("2006-01-02"), nil
+	}
+	if _, err := time.ParseInLocation("2006-01-02", s, time.Local); err != nil {
+		return "", fmt.Errorf("无法解析日期: %s", s)
+	}
+	return s, nil
+}
+
+// runJournalCommand gofarm journal: 按日期/操作/好友筛选并汇总操作日志
+func runJournalCommand(args []string) {
+	fs := flag.NewFlagSet("journal", flag.ExitOnError)
+	date := fs.String("date", "", "日期: 2006-01-02 / today / yesterday (默认全部)")
+	from := fs.String("from", "", "开始日期 (含)")
+	to := fs.String("to", "", "结束日期 (含)")
+	gid := fs.Int64("gid", 0, "只看该账号")
+	action := fs.String("action", "", "操作 (方法名), 如 Harvest / Plant / Sell / BuyGoods")
+	friend := fs.String("friend", "", "好友GID或昵称")
+	failed := fs.Bool("failed", false, "只看失败的操作")
+	by := fs.String("by", game.JournalGroupAction, "汇总方式: action / friend / date")
+	list := fs.Bool("list", false, "逐条列出操作")
+	fs.Parse(args)
+
+	now := time.Now().In(game.GameLocation)
+	filter := game.JournalFilter{GID: *gid, Action: *action, Friend: *friend, Failed: *failed}
+	var err error
+	if *date != "" {
+		if filter.From, err = parseJournalDate(*date, now); err == nil {
+			filter.To = filter.From
+		}
+	} else {
+		if filter.From, err = parseJournalDate(*from, now); err == nil {
+			filter.To, err = parseJournalDate(*to, now)
+		}
+	}
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	switch *by {
+	case game.JournalGroupAction, game.JournalGroupFriend, game.JournalGroupDate:
+	default:
+		fmt.Println("用法: gofarm journal [--date <日期>] [--from <日期>] [--to <日期>] [--action <操作>] [--friend <好友>] [--failed] [--by action|friend|date] [--list]")
+		os.Exit(1)
+	}
+
+	entries, err := game.LoadJournal(filter)
+	if err != nil {
+		fmt.Printf("读取操作日志失败: %v\n", err)
+		os.Exit(1)
+	}
+	if *list {
+		game.PrintJournalEntries(entries)
+		fmt.Println()
+	}
+	game.PrintJournalReport(entries, *by)
+}
@@ -5,7 +5,9 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"regexp"
 	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
@@ -28,6 +30,9 @@ QQ经典农场 挂机脚本 (Go版本)
 用法:
   gofarm --code <登录code> [--wx] [--interval <秒>] [--friend-interval <秒>] [--harvest-delay <秒>]
   gofarm --qr [--interval <秒>] [--friend-interval <秒>] [--harvest-delay <秒>]
+  gofarm plan --target-level <等级> [--by <截止时间>] [--level <当前等级>] [--exp <总经验>] [--lands <地块数>] [--online <在线时间段>]
+  gofarm journal [--date <日期>] [--action <操作>] [--friend <好友>] [--by action|friend|date] [--list]
+  gofarm friends list|export [--gid <账号GID>] [--inactive] [--days <天数>] [--csv|--json] [--out <文件>]
   gofarm --verify
   gofarm --decode <数据> [--hex] [--gate] [--type <消息类型>]
   gofarm --exp-analysis [--exp-level <等级>] [--exp-lands <地块数>] [--exp-out <目录>]
@@ -39,6 +44,39 @@ QQ经典农场 挂机脚本 (Go版本)
   --interval          自己农场巡查完成后等待秒数, 默认10秒, 最低10秒
   --friend-interval   好友巡查完成后等待秒数, 默认1秒, 最低1秒
   --harvest-delay     成熟后延时收获秒数, 默认0秒(立即收获)
+  --harvest-guard     防偷模式: 在作物成熟瞬间精确收获 (忽略 --harvest-delay)
+  --guard-lead        防偷模式提前触发的毫秒数, 默认100
+  --guard-not-ready   防偷模式中服务器表示作物未成熟的错误码, 逗号分隔 (默认任何服务器错误都在重试窗口内重试)
+  --no-bag-seeds      不使用背包中的种子, 总是从商店购买
+  --bag-seed-tolerance 背包种子每小时经验低于最佳种子多少百分比内仍使用, 默认20
+  --no-use-items      不自动打开礼包/使用道具
+  --use-allow-ids     允许自动使用的物品ID, 逗号分隔 (优先于类型规则)
+  --use-deny-ids      禁止自动使用的物品ID, 逗号分隔 (优先级最高)
+  --use-allow-types   允许自动使用的物品类型 (ItemInfo.type), 逗号分隔, 默认11 (礼包/宝箱)
+  --use-deny-types    禁止自动使用的物品类型, 逗号分隔
+  --use-land-types    需要作用在生长中土地上的物品类型, 逗号分隔, 默认7 (化肥)
+  --gold-floor        金币底线, 购买后余额不低于该值, 默认0
+  --seed-daily-cap    每日购买种子的金币上限, 默认0(不限)
+  --dry-run           模拟模式: 只查询不操作, 收获/种植/购买/出售等操作只记录计划到 ledger/dryrun.jsonl
+  --target-level      等级目标: 按规划选择种子, 尽快达到该等级
+  --by                等级目标截止时间, 如 "2026-10-23 20:00" / 72h / friday
+  --online            每天的在线时间段, 逗号分隔, 如 08:00-12:00,18:00-23:30 (离线期间无法补种)
+  --schedule          作息时间表, 分号分隔, 格式 模块=模式[x间隔倍数]@HH:MM-HH:MM[@星期]
+                      模块: farm/friend/task/warehouse/*, 模式: full/harvest-only/paused, 星期: 1-5 或 6,7
+                      harvest-only: 农场只收获不照料/购买/种植, 好友只偷菜不帮忙 (只适用于 farm/friend)
+  --cold-start        不恢复 state/<GID>.json 中的状态快照, 重新获取所有状态
+  --no-steal-timer    关闭定时偷菜 (只在巡查时偷已成熟的作物)
+  --friend-rules      好友规则文件, 默认 friend_rules.json (修改后自动生效)
+  --no-help-thefts    好友最近7天偷我们达到该次数且帮忙抵不过扣分时不再帮他, 默认3 (0 为总是帮忙)
+  --visit-cache       好友农场缓存有效期(分钟), 默认30 (0 为每次都进入农场)
+  --friend-workers    同时拜访的好友农场数, 默认1
+  --handle-applications 自动处理好友申请, 按以下规则同意 (不符合的保留或拒绝)
+  --apply-min-level   同意申请的最低等级
+  --apply-name        同意申请的昵称正则
+  --apply-max-friends 好友数上限, 达到后不再同意
+  --apply-wx-only     只同意微信好友的申请
+  --apply-reject      拒绝不符合规则的申请
+  --apply-block       好友数达到上限时屏蔽申请
   --verify            验证proto定义
   --decode            解码PB数据 (运行 --decode 无参数查看详细帮助)
   --exp-analysis      运行经验效率分析
@@ -50,12 +88,29 @@ QQ经典农场 挂机脚本 (Go版本)
   - 自动收获成熟作物 → 购买种子 → 种植 → 施肥
   - 自动除草、除虫、浇水
   - 自动铲除枯死作物
-  - 自动巡查好友农场: 帮忙浇水/除草/除虫 + 偷菜
+  - 自动巡查好友农场: 帮忙浇水/除草/除虫 + 偷菜 (每种操作一次请求处理所有土地)
   - 自动领取任务奖励 (支持分享翻倍)
   - 每分钟自动出售仓库果实
+  - 自动打开礼包/使用道具 (按物品ID或类型的允许/禁止列表)
+  - 临期物品追踪: 过期前按类型自动使用/出售, 贵重物品临期时提醒
   - 启动时读取 share.txt 处理邀请码 (仅微信)
   - 心跳保活
   - 经验效率分析: 计算最优种植策略并导出JSON/CSV
+  - 收获账本: 记录每块地/每种作物/每个来源的实际收益, 经验分析时对比理论值
+  - 被偷监控: 记录偷菜的好友/作物/数量, 每日输出汇总
+  - 等级目标规划: 结合等级经验表/地块数/在线时间段生成种植计划, 离线前优先种长周期作物
+  - 作息时间表: 各模块按时间段切换 正常/仅收获/暂停 模式并调整巡查间隔, 当前作息显示在状态栏
+  - 状态快照: 每分钟保存土地/操作限制/经验耗尽标记/好友/统计到 state/<GID>.json, 重启后恢复
+  - 定时偷菜: 按好友列表中的成熟倒计时排队, 作物成熟时立即拜访, 同时成熟的按果实价值排序
+  - 好友申请: 实时响应申请推送, 按等级/昵称/好友数上限/微信好友同意或拒绝, 好友满时屏蔽申请, 记录新加的好友
+  - 好友规则: 按GID或昵称设置 偷菜+帮忙/只帮忙/只偷菜/跳过, 可跳过不活跃的好友, 运行时修改规则文件即生效
+  - 每日重置: 按服务器时间和游戏时区 (UTC+8) 在0点清空各模块的每日限制和标记, 立即重新获取限制和任务
+  - 操作限制: 根据每次操作后变化的限制ID学习操作对应的限制, 按剩余次数/剩余经验次数决定是否继续
+  - 好友档案: 按账号保存好友资料、首次/最近出现时间和等级金币变化, 标记不活跃的好友, gofarm friends 查看和导出
+  - 并行巡查: 按预计收益排序后由多个农场同时拜访 (--friend-workers), 共用全局请求限速和剩余操作次数
+  - 好友农场缓存: 好友列表摘要与上次拜访时相同且按缓存土地的计时推算无可做操作时不进入农场
+  - 好友互惠: 记录好友偷菜/放草/放虫, 先偷最严重的小偷, 先帮从不使坏的好友, 不把帮忙次数花在只会偷菜的好友身上
+  - 操作日志: 每个改变游戏状态的操作 (目标/结果/获得和消耗的物品) 按天写入 ledger/journal-日期.jsonl
 
 邀请码文件 (share.txt):
   每行一个邀请链接，格式: ?uid=xxx&openid=xxx&share_source=xxx&doc_id=xxx
@@ -65,6 +120,14 @@ QQ经典农场 挂机脚本 (Go版本)
   gofarm --exp-analysis --exp-level 30 --exp-lands 18
   gofarm --exp-analysis --exp-level 50 --exp-lands 24 --exp-out ./output
   gofarm --code xxx --harvest-delay 300  # 成熟后延时5分钟收获
+  gofarm --code xxx --harvest-guard      # 成熟瞬间收获, 防止被偷
+  gofarm plan --target-level 40 --by friday --level 32 --lands 18 --online 08:00-23:30
+  gofarm --code xxx --target-level 40 --by friday --online 08:00-23:30
+  gofarm journal --date yesterday --by action   # 昨天做了哪些操作
+  gofarm journal --from 2026-10-01 --action Harvest --friend 张三 --list
+  gofarm friends list --inactive                # 超过7天没有等级或作物变化的好友
+  gofarm friends export --csv --out friends.csv
+  gofarm --code xxx --schedule "friend=paused@01:00-07:00;*=fullx3@00:00-07:00;farm=harvest-only@09:00-18:00@1-5"
 `)
 }
 
@@ -76,6 +139,37 @@ type Options struct {
 	Interval          int
 	FriendInterval    int
 	HarvestDelay      int
+	HarvestGuard      bool
+	GuardLeadMs       int
+	GuardNotReady     string
+	NoBagSeeds        bool
+	DryRun            bool
+	GoldFloor         int64
+	SeedDailyCap      int64
+	TargetLevel       int
+	Deadline          string
+	Online            string
+	Schedule          string
+	ColdStart         bool
+	NoStealTimer      bool
+	FriendRules       string
+	NoHelpThefts      int
+	VisitCacheMin     int
+	FriendWorkers     int
+	HandleApps        bool
+	ApplyMinLevel     int
+	ApplyName         string
+	ApplyMaxFriends   int
+	ApplyWxOnly       bool
+	ApplyReject       bool
+	ApplyBlock        bool
+	BagSeedTolerance  float64
+	NoUseItems        bool
+	UseAllowIDs       string
+	UseDenyIDs        string
+	UseAllowTypes     string
+	UseDenyTypes      string
+	UseLandTypes      string
 	Verify            bool
 	Decode            bool
 	DecodeData        string
@@ -97,6 +191,37 @@ func parseArgs() Options {
 	flag.IntVar(&opts.Interval, "interval", 10, "农场巡查间隔(秒)")
 	flag.IntVar(&opts.FriendInterval, "friend-interval", 10, "好友巡查间隔(秒)")
 	flag.IntVar(&opts.HarvestDelay, "harvest-delay", 0, "成熟后延时收获秒数")
+	flag.BoolVar(&opts.HarvestGuard, "harvest-guard", false, "防偷模式: 成熟瞬间收获")
+	flag.IntVar(&opts.GuardLeadMs, "guard-lead", 100, "防偷模式提前触发毫秒数")
+	flag.StringVar(&opts.GuardNotReady, "guard-not-ready", "", "防偷模式中表示未成熟的错误码")
+	flag.BoolVar(&opts.NoBagSeeds, "no-bag-seeds", false, "不使用背包种子")
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "模拟模式: 只记录操作计划")
+	flag.Int64Var(&opts.GoldFloor, "gold-floor", 0, "金币底线")
+	flag.Int64Var(&opts.SeedDailyCap, "seed-daily-cap", 0, "每日购买种子的金币上限")
+	flag.IntVar(&opts.TargetLevel, "target-level", 0, "等级目标")
+	flag.StringVar(&opts.Deadline, "by", "", "等级目标截止时间")
+	flag.StringVar(&opts.Online, "online", "", "每天的在线时间段")
+	flag.StringVar(&opts.Schedule, "schedule", "", "作息时间表")
+	flag.BoolVar(&opts.ColdStart, "cold-start", false, "不恢复状态快照")
+	flag.BoolVar(&opts.NoStealTimer, "no-steal-timer", false, "关闭定时偷菜")
+	flag.StringVar(&opts.FriendRules, "friend-rules", "", "好友规则文件")
+	flag.IntVar(&opts.NoHelpThefts, "no-help-thefts", 3, "好友偷我们达到该次数后不再帮他")
+	flag.IntVar(&opts.VisitCacheMin, "visit-cache", 30, "好友农场缓存有效期(分钟)")
+	flag.IntVar(&opts.FriendWorkers, "friend-workers", 1, "同时拜访的好友农场数")
+	flag.BoolVar(&opts.HandleApps, "handle-applications", false, "自动处理好友申请")
+	flag.IntVar(&opts.ApplyMinLevel, "apply-min-level", 0, "同意申请的最低等级")
+	flag.StringVar(&opts.ApplyName, "apply-name", "", "同意申请的昵称正则")
+	flag.IntVar(&opts.ApplyMaxFriends, "apply-max-friends", 0, "好友数上限")
+	flag.BoolVar(&opts.ApplyWxOnly, "apply-wx-only", false, "只同意微信好友的申请")
+	flag.BoolVar(&opts.ApplyReject, "apply-reject", false, "拒绝不符合规则的申请")
+	flag.BoolVar(&opts.ApplyBlock, "apply-block", false, "好友数达到上限时屏蔽申请")
+	flag.Float64Var(&opts.BagSeedTolerance, "bag-seed-tolerance", 20, "背包种子经验效率容差(百分比)")
+	flag.BoolVar(&opts.NoUseItems, "no-use-items", false, "不自动使用道具")
+	flag.StringVar(&opts.UseAllowIDs, "use-allow-ids", "", "允许自动使用的物品ID")
+	flag.StringVar(&opts.UseDenyIDs, "use-deny-ids", "", "禁止自动使用的物品ID")
+	flag.StringVar(&opts.UseAllowTypes, "use-allow-types", "", "允许自动使用的物品类型")
+	flag.StringVar(&opts.UseDenyTypes, "use-deny-types", "", "禁止自动使用的物品类型")
+	flag.StringVar(&opts.UseLandTypes, "use-land-types", "", "需要作用在土地上的物品类型")
 	flag.BoolVar(&opts.Verify, "verify", false, "验证proto定义")
 	flag.BoolVar(&opts.Decode, "decode", false, "解码PB数据")
 	flag.BoolVar(&opts.DecodeHex, "hex", false, "数据为hex编码")
@@ -122,6 +247,20 @@ func main() {
 	// 初始化日志
 	logger.InitFileLogger()
 
+	// 子命令
+	if len(os.Args) > 1 && os.Args[1] == "plan" {
+		runPlanCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "journal" {
+		runJournalCommand(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "friends" {
+		runFriendsCommand(os.Args[2:])
+		return
+	}
+
 	// 解析命令行参数
 	opts := parseArgs()
 
@@ -162,6 +301,13 @@ func main() {
 			fmt.Printf("经验分析失败: %v\n", err)
 			os.Exit(1)
 		}
+
+		// 有收获账本时对比实际收益
+		if err := game.Ledger.LoadFromFile(0); err == nil {
+			game.Ledger.PrintLedgerReport(opts.ExpLands)
+		}
+		game.Mutation.PrintMutationReport()
+		game.Reciprocity.PrintReciprocityReport()
 		return
 	}
 
@@ -180,6 +326,128 @@ func main() {
 	if opts.HarvestDelay >= 0 {
 		config.Current.HarvestDelay = time.Duration(opts.HarvestDelay) * time.Second
 	}
+	if opts.HarvestGuard {
+		config.Current.HarvestGuard = true
+	}
+	if opts.GuardLeadMs >= 0 {
+		config.Current.HarvestGuardLead = time.Duration(opts.GuardLeadMs) * time.Millisecond
+	}
+	if opts.GuardNotReady != "" {
+		codes, err := parseInt64List(opts.GuardNotReady)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		config.Current.GuardNotReadyCodes = codes
+	}
+	if opts.NoBagSeeds {
+		config.Current.UseBagSeeds = false
+	}
+	if opts.BagSeedTolerance >= 0 {
+		config.Current.BagSeedExpTolerance = opts.BagSeedTolerance
+	}
+	if opts.NoUseItems {
+		config.Current.AutoUseItems = false
+	}
+	if opts.UseAllowIDs != "" {
+		ids, err := parseIntList(opts.UseAllowIDs)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		config.Current.UseItemAllowIDs = ids
+	}
+	if opts.UseDenyIDs != "" {
+		ids, err := parseIntList(opts.UseDenyIDs)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		config.Current.UseItemDenyIDs = ids
+	}
+	if opts.UseAllowTypes != "" {
+		ids, err := parseIntList(opts.UseAllowTypes)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		config.Current.UseItemAllowTypes = ids
+	}
+	if opts.UseDenyTypes != "" {
+		ids, err := parseIntList(opts.UseDenyTypes)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		config.Current.UseItemDenyTypes = ids
+	}
+	if opts.UseLandTypes != "" {
+		ids, err := parseIntList(opts.UseLandTypes)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		config.Current.UseItemLandTypes = ids
+	}
+	if opts.GoldFloor > 0 {
+		config.Current.GoldFloor = opts.GoldFloor
+	}
+	if opts.SeedDailyCap > 0 {
+		config.Current.GoldDailyCaps[game.BudgetSeeds] = opts.SeedDailyCap
+	}
+	if err := parseOnline(opts.Online); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if opts.Schedule != "" {
+		rules, err := game.ParseScheduleRules(opts.Schedule)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		config.Current.Schedule = rules
+	}
+	if opts.TargetLevel > 0 {
+		deadline, err := parseDeadline(opts.Deadline, time.Now())
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		game.Planner.SetGoal(opts.TargetLevel, deadline)
+	}
+	if opts.ColdStart {
+		config.Current.WarmStart = false
+	}
+	if opts.NoStealTimer {
+		config.Current.StealScheduler = false
+	}
+	if opts.FriendRules != "" {
+		config.Current.FriendRulesFile = opts.FriendRules
+	}
+	config.Current.NoHelpTheftThreshold = opts.NoHelpThefts
+	config.Current.FriendVisitCacheTTL = time.Duration(opts.VisitCacheMin) * time.Minute
+	if opts.FriendWorkers > 0 {
+		config.Current.FriendPatrolWorkers = opts.FriendWorkers
+	}
+	if opts.HandleApps {
+		if opts.ApplyName != "" {
+			if _, err := regexp.Compile(opts.ApplyName); err != nil {
+				fmt.Printf("昵称正则无效: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		config.Current.HandleApplications = true
+		config.Current.ApplyMinLevel = opts.ApplyMinLevel
+		config.Current.ApplyNamePattern = opts.ApplyName
+		config.Current.ApplyMaxFriends = opts.ApplyMaxFriends
+		config.Current.ApplyWxOnly = opts.ApplyWxOnly
+		config.Current.ApplyRejectUnmatched = opts.ApplyReject
+		config.Current.ApplyBlockWhenFull = opts.ApplyBlock
+	}
+	if opts.DryRun {
+		config.Current.DryRun = true
+		fmt.Println("[模拟] 模拟模式已开启: 不会发送任何改变游戏状态的请求")
+	}
 
 	// 处理登录code
 	usedQrLogin := false
@@ -250,6 +518,20 @@ func main() {
 		// 处理邀请码（仅微信环境）
 		login.ProcessInviteCodes()
 
+		// 恢复上次运行的状态快照并定时保存
+		game.State.Restore(gid)
+		game.Directory.Load(gid)
+		game.State.StartAutoSave()
+
+		// 按服务器时间在游戏日0点重置每日限制
+		game.DayReset.Start()
+
+		// 启动被偷监控
+		game.Theft.StartTheftMonitor()
+
+		// 金币预算跟踪实时余额
+		game.Budget.StartListening()
+
 		// 启动农场巡查
 		fmt.Println("[系统] 启动农场巡查模块...")
 		game.Farm.StartFarmCheckLoop()
@@ -262,6 +544,14 @@ func main() {
 		fmt.Println("[系统] 好友巡查已启动")
 		fmt.Println()
 
+		// 处理好友申请 (延迟3秒，避免同时发送大量请求)
+		if config.Current.HandleApplications {
+			go func() {
+				time.Sleep(3 * time.Second)
+				game.Applications.StartApplicationHandler()
+			}()
+		}
+
 		// 启动任务系统 (延迟4秒，避免同时发送大量请求)
 		fmt.Println("[系统] 任务系统将在4秒后启动...")
 		go func() {
@@ -276,6 +566,34 @@ func main() {
 			game.Warehouse.StartSellLoop()
 		}()
 
+		// 启动道具系统 (延迟6秒，避免同时发送大量请求)
+		if config.Current.AutoUseItems {
+			fmt.Println("[系统] 道具系统将在6秒后启动...")
+			go func() {
+				time.Sleep(6 * time.Second)
+				game.Consumable.StartConsumableLoop()
+			}()
+		}
+
+		// 启动临期物品检查 (延迟7秒，避免同时发送大量请求)
+		network.Net.GetEvents().On("expiryWarning", func(data interface{}) {
+			if summary, ok := data.(string); ok {
+				status.UpdateStatusAlert(summary)
+			}
+		})
+		go func() {
+			time.Sleep(7 * time.Second)
+			game.Expiry.StartExpiryLoop()
+		}()
+
+		// 作息变化时刷新状态栏
+		network.Net.GetEvents().On("scheduleChanged", func(data interface{}) {
+			if text, ok := data.(string); ok {
+				status.UpdateStatusSchedule(text)
+			}
+		})
+		game.Schedule.StartStatusLoop()
+
 		fmt.Println("[系统] 所有核心模块启动中...")
 
 		// 监听断开连接事件（被踢下线或连接异常）
@@ -303,6 +621,17 @@ func main() {
 	game.Task.StopTaskCheckLoop()
 	fmt.Println("[退出] 正在停止仓库系统...")
 	game.Warehouse.StopSellLoop()
+	if config.Current.AutoUseItems {
+		fmt.Println("[退出] 正在停止道具系统...")
+		game.Consumable.StopConsumableLoop()
+	}
+	game.Expiry.StopExpiryLoop()
+	game.Schedule.StopStatusLoop()
+	game.Theft.StopTheftMonitor()
+	game.DayReset.Stop()
+	game.State.StopAutoSave()
+	game.DryRun.PrintSummary()
+	game.Farm.PrintHarvestGuardSummary()
 	status.CleanupStatusBar()
 	fmt.Println("[退出] 正在断开...")
 	network.Net.Cleanup()
@@ -321,3 +650,33 @@ func parseInt64(s string) int64 {
 	i, _ := strconv.ParseInt(s, 10, 64)
 	return i
 }
+
+// parseIntList 解析逗号分隔的整数列表 (int)
+func parseIntList(s string) ([]int, error) {
+	list, err := parseInt64List(s)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]int, len(list))
+	for i, v := range list {
+		result[i] = int(v)
+	}
+	return result, nil
+}
+
+// parseInt64List 解析逗号分隔的整数列表
+func parseInt64List(s string) ([]int64, error) {
+	var result []int64
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		i, err := strconv.ParseInt(part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无法解析整数列表 %q: %v", s, err)
+		}
+		result = append(result, i)
+	}
+	return result, nil
+}
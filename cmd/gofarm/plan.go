@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/game"
+)
+
+// 星期名称 (用于 --by friday / --by 周五)
+var weekdayNames = map[string]time.Weekday{
+	"sunday": time.Sunday, "monday": time.Monday, "tuesday": time.Tuesday, "wednesday": time.Wednesday,
+	"thursday": time.Thursday, "friday": time.Friday, "saturday": time.Saturday,
+	"周日": time.Sunday, "周一": time.Monday, "周二": time.Tuesday, "周三": time.Wednesday,
+	"周四": time.Thursday, "周五": time.Friday, "周六": time.Saturday,
+}
+
+// parseDeadline 解析截止时间: "2006-01-02 15:04" / "2006-01-02" / 时长 "72h" / 星期 "friday"
+func parseDeadline(s string, now time.Time) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", s, time.Local); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t.Add(24*time.Hour - time.Minute), nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return now.Add(d), nil
+	}
+	if wd, ok := weekdayNames[strings.ToLower(s)]; ok {
+		days := (int(wd) - int(now.Weekday()) + 7) % 7
+		end := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 0, 0, now.Location())
+		return end.AddDate(0, 0, days), nil
+	}
+	return time.Time{}, fmt.Errorf("无法解析截止时间: %s", s)
+}
+
+// parseOnline 解析逗号分隔的在线时间段并写入配置
+func parseOnline(s string) error {
+	if s == "" {
+		return nil
+	}
+	windows := strings.Split(s, ",")
+	if _, err := game.ParseOnlineWindows(windows); err != nil {
+		return err
+	}
+	config.Current.OnlineWindows = windows
+	return nil
+}
+
+// runPlanCommand gofarm plan --target-level N --by <时间>: 打印达到目标等级的种植计划
+func runPlanCommand(args []string) {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	targetLevel := fs.Int("target-level", 0, "目标等级")
+	by := fs.String("by", "", "截止时间: \"2006-01-02 15:04\" / \"2006-01-02\" / 时长如 72h / 星期如 friday")
+	gid := fs.Int64("gid", 0, "读取该账号的状态快照 state/<GID>.json (只有一个快照时可省略)")
+	level := fs.Int("level", 1, "当前等级 (默认读取状态快照)")
+	exp := fs.Int64("exp", -1, "当前总经验 (默认读取状态快照, 否则为当前等级的起始经验)")
+	lands := fs.Int("lands", 18, "地块数 (默认读取状态快照)")
+	online := fs.String("online", "", "每天的在线时间段, 逗号分隔, 如 08:00-12:00,18:00-23:30 (默认全天在线)")
+	fs.Parse(args)
+
+	if *targetLevel <= 0 {
+		fmt.Println("用法: gofarm plan --target-level <等级> [--by <截止时间>] [--gid <账号GID>] [--level <当前等级>] [--exp <当前总经验>] [--lands <地块数>] [--online <在线时间段>]")
+		os.Exit(1)
+	}
+
+	// 未在命令行指定的等级/经验/地块数从状态快照读取
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var snapGID int64
+	if !set["level"] || !set["exp"] || !set["lands"] {
+		snap, err := game.LoadSnapshot(*gid)
+		if err != nil {
+			fmt.Printf("未读取到状态快照 (%v), 使用命令行参数\n", err)
+		} else {
+			snapGID = snap.GID
+			if !set["level"] && snap.Level > 0 {
+				*level = snap.Level
+				if !set["exp"] {
+					*exp = snap.Exp
+				}
+			}
+			if plots := snap.PlotCount(); !set["lands"] && plots > 0 {
+				*lands = plots
+			}
+		}
+	}
+
+	now := time.Now()
+	deadline, err := parseDeadline(*by, now)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	if err := parseOnline(*online); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	totalExp := *exp
+	if totalExp < 0 {
+		totalExp = game.Config.GetLevelExpTable()[*level]
+	}
+	if snapGID != 0 {
+		fmt.Printf("账号 %d: Lv%d, 总经验 %d, %d 块地\n", snapGID, *level, totalExp, *lands)
+	}
+
+	game.Planner.SetGoal(*targetLevel, deadline)
+	plan := game.Planner.Plan(*level, totalExp, *lands, now)
+	game.Planner.PrintPlan(plan)
+}
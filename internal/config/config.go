@@ -37,6 +37,16 @@ type DeviceInfo struct {
 	DeviceID      string `json:"device_id"`
 }
 
+// 作息规则: 在指定星期的时间段内，模块按指定模式运行
+type ScheduleRule struct {
+	Module        string  // 模块: farm/friend/task/warehouse, "*" 表示所有模块
+	Days          []int   // 星期 (0=周日 ... 6=周六), 为空表示每天
+	Start         string  // 开始时间 "HH:MM"
+	End           string  // 结束时间 "HH:MM" (小于开始时间表示跨零点)
+	Mode          string  // 模式: full/harvest-only/paused
+	IntervalScale float64 // 巡查间隔倍数 (如 3 表示间隔放大3倍, 0 或 1 表示不变)
+}
+
 // 全局配置
 type Config struct {
 	ServerUrl            string
@@ -47,7 +57,51 @@ type Config struct {
 	FarmCheckInterval    time.Duration
 	FriendCheckInterval  time.Duration
 	ForceLowestLevelCrop bool
-	HarvestDelay         time.Duration // 延时收获时间
+	HarvestDelay         time.Duration    // 延时收获时间
+	HarvestGuard         bool             // 防偷模式: 成熟瞬间精确收获 (忽略延时收获)
+	HarvestGuardLead     time.Duration    // 防偷模式提前多久触发收获
+	HarvestGuardRetries  int              // 防偷模式收获失败(未成熟)时的重试次数
+	HarvestGuardRetryGap time.Duration    // 防偷模式重试间隔
+	GuardNotReadyCodes   []int64          // 服务器表示作物未成熟的错误码 (为空时防偷模式对任何服务器错误在重试窗口内重试)
+	UseBagSeeds          bool             // 种植前优先使用背包中的种子
+	BagSeedExpTolerance  float64          // 背包种子每小时经验与最佳种子的允许差距(百分比)
+	AlwaysUseEventSeeds  bool             // 活动种子(商店不出售)无论效率都优先用掉
+	AutoUseItems         bool             // 自动打开礼包/使用消耗品
+	UseItemAllowIDs      []int            // 允许自动使用的物品ID (优先于类型规则)
+	UseItemDenyIDs       []int            // 禁止自动使用的物品ID (优先级最高)
+	UseItemAllowTypes    []int            // 允许自动使用的物品类型 (ItemInfo.type)
+	UseItemDenyTypes     []int            // 禁止自动使用的物品类型
+	UseItemLandTypes     []int            // 需要作用在土地上的物品类型
+	ExpiryPolicies       map[int]string   // 物品类型 -> 临期处理方式 (use/sell/warn)
+	ExpiryActLead        time.Duration    // 提前多久处理临期物品
+	ExpiryWarnLead       time.Duration    // 提前多久提醒临期物品
+	ExpiryWarnRarity     int              // 稀有度不低于该值的物品视为贵重物品
+	KeepMutantFruit      bool             // 变异作物收获的果实不自动出售
+	HarvestMutantsFirst  bool             // 变异作物优先单独收获
+	ProtectMutants       bool             // 未收获的变异作物不铲除
+	DryRun               bool             // 模拟模式: 只读请求照常发送, 改变游戏状态的请求只记录计划
+	GoldFloor            int64            // 金币底线: 任何支出后余额不低于该值
+	GoldDailyCaps        map[string]int64 // 支出类别 -> 每日上限 (0 或未配置为不限)
+	GoldPriority         []string         // 支出类别优先级 (高优先级类别当天未用完的额度对低优先级类别保留)
+	OnlineWindows        []string         // 每天的在线时间段, 如 "08:00-23:30" (为空表示全天在线)
+	Schedule             []ScheduleRule   // 作息时间表: 按时间段设置各模块的运行模式 (按顺序匹配, 未匹配时全速运行)
+	WarmStart            bool             // 启动时从 state/<GID>.json 恢复状态快照 (土地/操作限制/经验耗尽标记/好友/计数)
+	StealScheduler       bool             // 定时偷菜: 按好友作物的成熟倒计时在成熟时立即拜访
+	StealRipeDelay       time.Duration    // 定时偷菜在成熟后多久拜访 (留出时间误差)
+	StealMinGap          time.Duration    // 定时偷菜两次拜访的最小间隔
+	FriendRulesFile      string           // 好友规则文件 (按GID或昵称设置偷菜/帮忙/跳过, 修改后自动生效)
+	NoHelpTheftThreshold int              // 好友最近偷我们达到该次数 (或放过草虫) 且帮忙抵不过扣分时不再帮他
+	FriendVisitCacheTTL  time.Duration    // 好友农场缓存有效期: 摘要没有变化且推算无可做操作时不进入农场 (0 为关闭)
+	FriendPatrolWorkers  int              // 同时拜访的好友农场数 (每个农场内仍按 进入→操作→离开 顺序)
+	RequestMinInterval   time.Duration    // 所有游戏请求之间的最小间隔 (全局限速)
+	FriendInactiveDays   int              // 好友超过该天数没有等级或作物变化视为不活跃
+	HandleApplications   bool             // 自动处理好友申请 (实时响应申请推送)
+	ApplyMinLevel        int              // 同意申请的最低等级
+	ApplyNamePattern     string           // 同意申请的昵称正则 (为空不限)
+	ApplyMaxFriends      int              // 好友数上限, 达到后不再同意 (0 为不限)
+	ApplyWxOnly          bool             // 只同意微信好友的申请 (带 open_id)
+	ApplyRejectUnmatched bool             // 拒绝不符合规则的申请 (否则保留待手动处理)
+	ApplyBlockWhenFull   bool             // 好友数达到上限时屏蔽申请, 低于上限时解除
 	DeviceInfo           DeviceInfo
 }
 
@@ -62,6 +116,51 @@ var DefaultConfig = Config{
 	FriendCheckInterval:  10 * time.Second,
 	ForceLowestLevelCrop: false,
 	HarvestDelay:         0, // 默认不延时
+	HarvestGuard:         false,
+	HarvestGuardLead:     100 * time.Millisecond,
+	HarvestGuardRetries:  5,
+	HarvestGuardRetryGap: 200 * time.Millisecond,
+	UseBagSeeds:          true,
+	BagSeedExpTolerance:  20,
+	AlwaysUseEventSeeds:  true,
+	AutoUseItems:         true,
+	UseItemAllowTypes:    []int{11}, // 礼包/宝箱
+	UseItemLandTypes:     []int{7},  // 化肥
+	ExpiryPolicies: map[int]string{
+		6:  "sell", // 果实
+		7:  "use",  // 化肥
+		9:  "use",  // 狗粮
+		11: "use",  // 礼包/宝箱
+	},
+	ExpiryActLead:        30 * time.Minute,
+	ExpiryWarnLead:       24 * time.Hour,
+	ExpiryWarnRarity:     3,
+	KeepMutantFruit:      true,
+	HarvestMutantsFirst:  true,
+	ProtectMutants:       true,
+	DryRun:               false,
+	GoldFloor:            0,
+	GoldDailyCaps:        map[string]int64{},
+	GoldPriority:         []string{"seeds"},
+	OnlineWindows:        nil,
+	Schedule:             nil,
+	WarmStart:            true,
+	StealScheduler:       true,
+	StealRipeDelay:       500 * time.Millisecond,
+	StealMinGap:          2 * time.Second,
+	FriendRulesFile:      "friend_rules.json",
+	NoHelpTheftThreshold: 3,
+	FriendVisitCacheTTL:  30 * time.Minute,
+	FriendPatrolWorkers:  1,
+	RequestMinInterval:   50 * time.Millisecond,
+	FriendInactiveDays:   7,
+	HandleApplications:   false,
+	ApplyMinLevel:        0,
+	ApplyNamePattern:     "",
+	ApplyMaxFriends:      0,
+	ApplyWxOnly:          false,
+	ApplyRejectUnmatched: false,
+	ApplyBlockWhenFull:   false,
 	DeviceInfo: DeviceInfo{
 		ClientVersion: "1.6.0.14_20251224",
 		SysSoftware:   "iOS 26.2.1",
@@ -0,0 +1,251 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"gofarm/internal/config"
+	"gofarm/internal/network"
+	"gofarm/proto/gamepb/notifypb"
+	"gofarm/proto/gamepb/userpb"
+)
+
+// 金币支出类别
+// 目前只有商店购买种子会花金币: 协议中没有购买化肥和解锁/升级土地的请求，加入这些支出时再增加类别
+const (
+	BudgetSeeds = "seeds" // 购买种子
+)
+
+// GoldBudget 金币预算: 保留金币底线、按类别限制每日支出、按优先级分配
+type GoldBudget struct {
+	networkEvents *network.EventEmitter
+	gold          int64            // 实时金币余额 (-1 表示尚未收到推送)
+	spent         map[string]int64 // 类别 -> 当天已支出
+	dateKey       string
+	listening     bool
+	mu            sync.Mutex
+}
+
+var Budget *GoldBudget
+
+func init() {
+	Budget = &GoldBudget{
+		networkEvents: network.Net.GetEvents(),
+		gold:          -1,
+		spent:         make(map[string]int64),
+		dateKey:       getGameDateKey(),
+	}
+}
+
+// budgetCategoryName 类别名称
+func budgetCategoryName(category string) string {
+	switch category {
+	case BudgetSeeds:
+		return "种子"
+	default:
+		return category
+	}
+}
+
+// StartListening 监听金币变化推送，保持实时余额
+func (gb *GoldBudget) StartListening() {
+	gb.mu.Lock()
+	defer gb.mu.Unlock()
+	if gb.listening {
+		return
+	}
+	gb.listening = true
+
+	gb.networkEvents.On("basicNotify", gb.handleBasicNotify)
+	gb.networkEvents.On("itemNotify", gb.handleItemNotify)
+}
+
+// handleBasicNotify 处理基本信息推送中的金币
+func (gb *GoldBudget) handleBasicNotify(data interface{}) {
+	body, ok := data.([]byte)
+	if !ok {
+		return
+	}
+	var notify userpb.BasicNotify
+	if err := proto.Unmarshal(body, &notify); err != nil || notify.Basic == nil {
+		return
+	}
+	if notify.Basic.Gold > 0 {
+		gb.setGold(notify.Basic.Gold)
+	}
+}
+
+// handleItemNotify 处理物品变化推送中的金币
+func (gb *GoldBudget) handleItemNotify(data interface{}) {
+	body, ok := data.([]byte)
+	if !ok {
+		return
+	}
+	var notify notifypb.ItemNotify
+	if err := proto.Unmarshal(body, &notify); err != nil {
+		return
+	}
+	for _, chg := range notify.Items {
+		if chg != nil && chg.Item != nil && chg.Item.Id == GoldItemID {
+			gb.setGold(chg.Item.Count)
+			network.Net.GetUserState().UpdateGold(chg.Item.Count)
+		}
+	}
+}
+
+// setGold 更新实时余额
+func (gb *GoldBudget) setGold(gold int64) {
+	gb.mu.Lock()
+	gb.gold = gold
+	gb.mu.Unlock()
+}
+
+// Balance 当前金币余额 (优先使用推送的实时余额)
+func (gb *GoldBudget) Balance() int64 {
+	gb.mu.Lock()
+	gold := gb.gold
+	gb.mu.Unlock()
+	if gold >= 0 {
+		return gold
+	}
+	_, _, _, gold, _ = network.Net.GetUserState().Get()
+	return gold
+}
+
+// ResetDaily 每日重置时清空已支出
+func (gb *GoldBudget) ResetDaily() {
+	gb.mu.Lock()
+	defer gb.mu.Unlock()
+	gb.checkDailyReset()
+}
+
+// spentToday 当天各类别已支出 (用于状态快照)
+func (gb *GoldBudget) spentToday() map[string]int64 {
+	gb.mu.Lock()
+	defer gb.mu.Unlock()
+	gb.checkDailyReset()
+	result := make(map[string]int64, len(gb.spent))
+	for category, spent := range gb.spent {
+		result[category] = spent
+	}
+	return result
+}
+
+// restoreSpent 恢复快照中当天的支出
+func (gb *GoldBudget) restoreSpent(spent map[string]int64) {
+	gb.mu.Lock()
+	defer gb.mu.Unlock()
+	gb.checkDailyReset()
+	for category, amount := range spent {
+		gb.spent[category] += amount
+	}
+}
+
+// checkDailyReset 跨天清空已支出 (调用方持有锁)
+func (gb *GoldBudget) checkDailyReset() {
+	today := getGameDateKey()
+	if today != gb.dateKey {
+		gb.dateKey = today
+		gb.spent = make(map[string]int64)
+	}
+}
+
+// remainingCap 类别当天剩余额度，-1 表示不限 (调用方持有锁)
+func (gb *GoldBudget) remainingCap(category string) int64 {
+	limit, ok := config.Current.GoldDailyCaps[category]
+	if !ok || limit <= 0 {
+		return -1
+	}
+	left := limit - gb.spent[category]
+	if left < 0 {
+		left = 0
+	}
+	return left
+}
+
+// floorFor 类别的金币底线: 全局底线 + 优先级更高类别当天剩余的额度
+func (gb *GoldBudget) floorFor(category string) int64 {
+	floor := config.Current.GoldFloor
+	for _, higher := range config.Current.GoldPriority {
+		if higher == category {
+			break
+		}
+		if left := gb.remainingCap(higher); left > 0 {
+			floor += left
+		}
+	}
+	return floor
+}
+
+// Available 类别当前可支出的金币
+func (gb *GoldBudget) Available(category string) int64 {
+	balance := gb.Balance()
+
+	gb.mu.Lock()
+	defer gb.mu.Unlock()
+	gb.checkDailyReset()
+
+	available := balance - gb.floorFor(category)
+	if left := gb.remainingCap(category); left >= 0 && left < available {
+		available = left
+	}
+	if available < 0 {
+		available = 0
+	}
+	return available
+}
+
+// Allow 按预算计算最多可购买的数量 (不超过 count)
+func (gb *GoldBudget) Allow(category string, unitPrice int64, count int) int {
+	if unitPrice <= 0 {
+		return count
+	}
+	allowed := int(gb.Available(category) / unitPrice)
+	if allowed > count {
+		allowed = count
+	}
+	return allowed
+}
+
+// Approve 检查一笔支出是否在预算内
+func (gb *GoldBudget) Approve(category string, amount int64) error {
+	if available := gb.Available(category); amount > available {
+		return fmt.Errorf("%s预算不足: 需要 %d 金币, 可用 %d 金币 (余额 %d, 底线 %d)",
+			budgetCategoryName(category), amount, available, gb.Balance(), config.Current.GoldFloor)
+	}
+	return nil
+}
+
+// Spend 记录一笔支出
+func (gb *GoldBudget) Spend(category string, amount int64) {
+	if amount <= 0 || IsDryRun() {
+		return
+	}
+	gb.mu.Lock()
+	defer gb.mu.Unlock()
+	gb.checkDailyReset()
+	gb.spent[category] += amount
+	if gb.gold >= 0 {
+		gb.gold -= amount
+	}
+}
+
+// Summary 当天支出摘要
+func (gb *GoldBudget) Summary() string {
+	gb.mu.Lock()
+	defer gb.mu.Unlock()
+	gb.checkDailyReset()
+
+	parts := make([]string, 0, len(config.Current.GoldPriority))
+	for _, category := range config.Current.GoldPriority {
+		text := fmt.Sprintf("%s %d", budgetCategoryName(category), gb.spent[category])
+		if limit, ok := config.Current.GoldDailyCaps[category]; ok && limit > 0 {
+			text += fmt.Sprintf("/%d", limit)
+		}
+		parts = append(parts, text)
+	}
+	return fmt.Sprintf("今日支出: %s (底线 %d)", strings.Join(parts, ", "), config.Current.GoldFloor)
+}
@@ -32,14 +32,35 @@ type Plant struct {
 	Exp         int    `json:"exp"`
 	GrowPhases  string `json:"grow_phases"`
 	UnlockLevel int    `json:"unlock_level"`
+	Mutant      string `json:"mutant"` // 变异配置ID -> 变异后植物ID, 如 "1:102003;2:1020059"
+	Size        int    `json:"size"`   // 占地尺寸, >1 为需要多块地的大作物
 }
 
 // 物品配置
 type ItemInfo struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
+	ID              int    `json:"id"`
+	Type            int    `json:"type"`
+	Name            string `json:"name"`
+	InteractionType string `json:"interaction_type"`
+	Price           int64  `json:"price"`
+	Level           int    `json:"level"`
+	CanUse          int    `json:"can_use"` // 0=不可使用, 1=可使用, >1=达到该等级后可使用
+	Rarity          int    `json:"rarity"`
+	Desc            string `json:"desc"`
 }
 
+// 物品类型 (ItemInfo.type)
+const (
+	ItemTypeCurrency   = 2  // 货币
+	ItemTypeSeed       = 5  // 种子
+	ItemTypeFruit      = 6  // 果实
+	ItemTypeFertilizer = 7  // 化肥
+	ItemTypeDogFood    = 9  // 狗粮
+	ItemTypeGiftPack   = 11 // 礼包/宝箱
+	ItemTypeUnlockCard = 14 // 种子解锁卡
+	ItemTypeOptionPack = 16 // 自选礼包
+)
+
 // 游戏配置管理器
 type ConfigManager struct {
 	roleLevelConfig []RoleLevel
@@ -248,3 +269,36 @@ func (cm *ConfigManager) GetItemName(itemID int) string {
 	}
 	return fmt.Sprintf("未知物品%d", itemID)
 }
+
+// 获取植物的变异配置 (变异配置ID -> 变异后植物ID)
+func (cm *ConfigManager) GetPlantMutants(plantID int) map[int]int {
+	result := make(map[int]int)
+	plant := cm.plantMap[plantID]
+	if plant == nil || plant.Mutant == "" {
+		return result
+	}
+
+	// 解析 "1:102003;2:1020059" 格式
+	for _, pair := range strings.Split(plant.Mutant, ";") {
+		parts := strings.Split(pair, ":")
+		if len(parts) != 2 {
+			continue
+		}
+		configID, err1 := strconv.Atoi(parts[0])
+		targetID, err2 := strconv.Atoi(parts[1])
+		if err1 == nil && err2 == nil {
+			result[configID] = targetID
+		}
+	}
+	return result
+}
+
+// 获取变异名称 (优先使用变异后的植物名称)
+func (cm *ConfigManager) GetMutantName(plantID, mutantConfigID int) string {
+	if targetID, ok := cm.GetPlantMutants(plantID)[mutantConfigID]; ok {
+		if target := cm.plantMap[targetID]; target != nil {
+			return target.Name
+		}
+	}
+	return fmt.Sprintf("变异%d", mutantConfigID)
+}
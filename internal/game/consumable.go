@@ -0,0 +1,248 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/corepb"
+	"gofarm/proto/gamepb/itempb"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// ConsumableManager 消耗品管理器 (自动开礼包/使用道具)
+type ConsumableManager struct {
+	isChecking    bool
+	checkTimer    *time.Timer
+	loopRunning   bool
+	networkEvents *network.EventEmitter
+}
+
+var Consumable *ConsumableManager
+
+// 配置: 消耗品检查间隔
+const ConsumableCheckInterval = 5 * time.Minute
+
+func init() {
+	Consumable = &ConsumableManager{
+		networkEvents: network.Net.GetEvents(),
+	}
+}
+
+// UseItem 使用物品 (landIds 为空表示不作用在土地上)
+func (cm *ConsumableManager) UseItem(itemID, count int64, landIds []int64) (*itempb.UseReply, error) {
+	req := &itempb.UseRequest{
+		ItemId:  itemID,
+		Count:   count,
+		LandIds: landIds,
+	}
+	resp := &itempb.UseReply{}
+
+	err := sendGameRequest("gamepb.itempb.ItemService", "Use", req, resp, 10*time.Second)
+	return resp, err
+}
+
+// BatchUseItems 批量使用物品
+func (cm *ConsumableManager) BatchUseItems(items []*itempb.UseItem) (*itempb.BatchUseReply, error) {
+	req := &itempb.BatchUseRequest{
+		Items: items,
+	}
+	resp := &itempb.BatchUseReply{}
+
+	err := sendGameRequest("gamepb.itempb.ItemService", "BatchUse", req, resp, 10*time.Second)
+	return resp, err
+}
+
+// containsInt 检查切片是否包含指定值
+func containsInt(slice []int, val int) bool {
+	for _, v := range slice {
+		if v == val {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAutoUse 按允许/禁止列表判断物品是否可以自动使用
+func (cm *ConsumableManager) CanAutoUse(info *ItemInfo, level int) bool {
+	if info == nil || info.CanUse <= 0 {
+		return false
+	}
+	// can_use 大于1时表示使用所需等级
+	if info.CanUse > 1 && level < info.CanUse {
+		return false
+	}
+
+	cfg := config.Current
+	if containsInt(cfg.UseItemDenyIDs, info.ID) {
+		return false
+	}
+	if containsInt(cfg.UseItemAllowIDs, info.ID) {
+		return true
+	}
+	if containsInt(cfg.UseItemDenyTypes, info.Type) {
+		return false
+	}
+	return containsInt(cfg.UseItemAllowTypes, info.Type)
+}
+
+// isLandTargetItem 检查物品是否需要作用在土地上
+func (cm *ConsumableManager) isLandTargetItem(info *ItemInfo) bool {
+	return info != nil && containsInt(config.Current.UseItemLandTypes, info.Type)
+}
+
+// UsableItem 待使用的物品
+type UsableItem struct {
+	Info  *ItemInfo
+	Count int64
+}
+
+// AnalyzeUsableItems 从背包物品中筛选可自动使用的物品
+func (cm *ConsumableManager) AnalyzeUsableItems(items []*corepb.Item, level int) []*UsableItem {
+	itemMap := make(map[int64]*UsableItem)
+	var result []*UsableItem
+
+	for _, item := range items {
+		if item == nil || item.Count <= 0 {
+			continue
+		}
+		info := Config.GetItemInfoByID(int(item.Id))
+		if !cm.CanAutoUse(info, level) {
+			continue
+		}
+		if usable, ok := itemMap[item.Id]; ok {
+			usable.Count += item.Count
+			continue
+		}
+		usable := &UsableItem{Info: info, Count: item.Count}
+		itemMap[item.Id] = usable
+		result = append(result, usable)
+	}
+
+	return result
+}
+
+// landHasItemEffect 土地当前生长阶段是否已使用过该物品
+func landHasItemEffect(land *plantpb.LandInfo, itemID int64, nowSec int64) bool {
+	if land.Plant == nil {
+		return false
+	}
+	phase := Farm.getCurrentPhase(land.Plant.Phases, nowSec)
+	return phase != nil && phase.FertsUsed[itemID] > 0
+}
+
+// UseLandItem 在生长中的土地上使用物品 (大作物只作用主地，跳过已有该物品效果的土地)，返回实际使用数量
+func (cm *ConsumableManager) UseLandItem(usable *UsableItem) int64 {
+	lands := Farm.GetLastLands()
+	if len(lands) == 0 {
+		return 0
+	}
+
+	itemID := int64(usable.Info.ID)
+	growing := Farm.AnalyzeLands(lands).Growing
+	nowSec := utils.GetServerTimeSec()
+	var targets []int64
+	for _, land := range MasterLands(lands) {
+		if int64(len(targets)) >= usable.Count {
+			break
+		}
+		if containsInt64(growing, land.Id) && !landHasItemEffect(land, itemID, nowSec) {
+			targets = append(targets, land.Id)
+		}
+	}
+	if len(targets) == 0 {
+		return 0
+	}
+
+	reply, err := cm.UseItem(itemID, int64(len(targets)), targets)
+	if err != nil {
+		utils.LogWarn("道具", fmt.Sprintf("对 %d 块地使用 %s 失败: %v", len(targets), usable.Info.Name, err))
+		return 0
+	}
+	utils.Log("道具", fmt.Sprintf("对 %d 块地使用 %s → %s", len(targets), usable.Info.Name, Task.formatRewardItems(reply.Items)))
+	return int64(len(targets))
+}
+
+// UseAllConsumables 检查背包并使用所有符合规则的消耗品
+func (cm *ConsumableManager) UseAllConsumables() {
+	if !config.Current.AutoUseItems || cm.isChecking {
+		return
+	}
+	cm.isChecking = true
+	defer func() { cm.isChecking = false }()
+
+	bagReply, err := Warehouse.GetBag()
+	if err != nil {
+		utils.LogWarn("道具", fmt.Sprintf("获取背包失败: %v", err))
+		return
+	}
+
+	_, _, level, _, _ := network.Net.GetUserState().Get()
+	usables := cm.AnalyzeUsableItems(Warehouse.getBagItems(bagReply), level)
+	if len(usables) == 0 {
+		return
+	}
+
+	// 作用在土地上的物品按物品一次作用到所有目标土地，其余物品一次性批量使用
+	var batch []*itempb.UseItem
+	var batchNames []string
+	for _, usable := range usables {
+		if cm.isLandTargetItem(usable.Info) {
+			cm.UseLandItem(usable)
+			continue
+		}
+		batch = append(batch, &itempb.UseItem{
+			ItemId: int64(usable.Info.ID),
+			Count:  usable.Count,
+		})
+		batchNames = append(batchNames, fmt.Sprintf("%s x%d", usable.Info.Name, usable.Count))
+	}
+
+	if len(batch) == 0 {
+		return
+	}
+
+	reply, err := cm.BatchUseItems(batch)
+	if err != nil {
+		utils.LogWarn("道具", fmt.Sprintf("批量使用 %v 失败: %v", batchNames, err))
+		return
+	}
+	utils.Log("道具", fmt.Sprintf("使用 %v → %s", batchNames, Task.formatRewardItems(reply.Items)))
+}
+
+// StartConsumableLoop 启动消耗品使用循环
+func (cm *ConsumableManager) StartConsumableLoop() {
+	if cm.loopRunning {
+		return
+	}
+
+	cm.loopRunning = true
+	utils.Log("道具", "自动使用道具循环已启动")
+
+	// 立即执行一次
+	go cm.UseAllConsumables()
+
+	// 定时器循环
+	go func() {
+		for cm.loopRunning {
+			time.Sleep(ConsumableCheckInterval)
+
+			if !cm.loopRunning {
+				break
+			}
+
+			cm.UseAllConsumables()
+		}
+	}()
+}
+
+// StopConsumableLoop 停止消耗品使用循环
+func (cm *ConsumableManager) StopConsumableLoop() {
+	cm.loopRunning = false
+	if cm.checkTimer != nil {
+		cm.checkTimer.Stop()
+	}
+	utils.Log("道具", "自动使用道具循环已停止")
+}
@@ -0,0 +1,123 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+)
+
+// GameLocation 游戏服务器所在时区 (每日限制在该时区的0点刷新)
+var GameLocation = time.FixedZone("UTC+8", 8*3600)
+
+// gameNow 按同步后的服务器时间换算的游戏时区当前时间
+func gameNow() time.Time {
+	return time.UnixMilli(utils.GetServerTimeMs()).In(GameLocation)
+}
+
+// getGameDateKey 获取游戏日期键 (YYYY-MM-DD, 按服务器时间和游戏时区)
+func getGameDateKey() string {
+	return gameNow().Format("2006-01-02")
+}
+
+// DayResetter 每日重置: 游戏日变化时清空各模块缓存的限制和标记，发出 "dayRollover" 事件并重新获取限制
+type DayResetter struct {
+	dateKey       string
+	networkEvents *network.EventEmitter
+	timer         *time.Timer
+	running       bool
+	mu            sync.Mutex
+}
+
+var DayReset *DayResetter
+
+func init() {
+	DayReset = &DayResetter{
+		dateKey:       getGameDateKey(),
+		networkEvents: network.Net.GetEvents(),
+	}
+}
+
+// Check 检查游戏日是否变化，变化时执行重置，返回是否重置
+func (dr *DayResetter) Check() bool {
+	today := getGameDateKey()
+	dr.mu.Lock()
+	prev := dr.dateKey
+	if today == prev {
+		dr.mu.Unlock()
+		return false
+	}
+	dr.dateKey = today
+	dr.mu.Unlock()
+
+	dr.rollover(prev, today)
+	return true
+}
+
+// rollover 清空各模块的每日状态并重新获取限制
+func (dr *DayResetter) rollover(prev, today string) {
+	utils.Log("每日重置", fmt.Sprintf("游戏日 %s → %s, 重置每日限制", prev, today))
+
+	OpLimits.Reset()
+	Friend.resetDailyLimits()
+	Task.resetDaily()
+	Theft.checkDayRollover()
+	Budget.ResetDaily()
+
+	dr.networkEvents.Emit("dayRollover", today)
+
+	// 立即重新获取当天的限制和任务
+	if _, err := Farm.GetAllLands(); err != nil {
+		utils.LogWarn("每日重置", fmt.Sprintf("重新获取操作限制失败: %v", err))
+	}
+	if _, err := Task.GetTaskInfo(); err != nil {
+		utils.LogWarn("每日重置", fmt.Sprintf("重新获取任务失败: %v", err))
+	}
+}
+
+// nextRolloverDelay 距离下一个游戏日0点的时间 (多等1秒避免时钟误差)
+func nextRolloverDelay() time.Duration {
+	now := gameNow()
+	next := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, GameLocation)
+	return next.Sub(now) + time.Second
+}
+
+// arm 设置下一次0点的定时器 (调用方持有锁)
+func (dr *DayResetter) arm() {
+	if dr.timer != nil {
+		dr.timer.Stop()
+	}
+	dr.timer = time.AfterFunc(nextRolloverDelay(), func() {
+		dr.Check()
+		dr.mu.Lock()
+		if dr.running {
+			dr.arm()
+		}
+		dr.mu.Unlock()
+	})
+}
+
+// Start 按服务器时间在每个游戏日0点执行重置
+func (dr *DayResetter) Start() {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	if dr.running {
+		return
+	}
+	dr.running = true
+	dr.dateKey = getGameDateKey() // 登录后服务器时间已同步
+	dr.arm()
+}
+
+// Stop 停止每日重置定时器
+func (dr *DayResetter) Stop() {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	dr.running = false
+	if dr.timer != nil {
+		dr.timer.Stop()
+		dr.timer = nil
+	}
+}
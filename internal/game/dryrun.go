@@ -0,0 +1,294 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"gofarm/internal/config"
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/corepb"
+	"gofarm/proto/gamepb/friendpb"
+	"gofarm/proto/gamepb/itempb"
+	"gofarm/proto/gamepb/plantpb"
+	"gofarm/proto/gamepb/shoppb"
+	"gofarm/proto/gamepb/taskpb"
+	"gofarm/proto/gamepb/userpb"
+)
+
+// 会改变游戏状态的请求 (模拟模式下拦截)
+var mutatingMethods = map[string]bool{
+	"gamepb.plantpb.PlantService.Harvest":                true,
+	"gamepb.plantpb.PlantService.Plant":                  true,
+	"gamepb.plantpb.PlantService.RemovePlant":            true,
+	"gamepb.plantpb.PlantService.Fertilize":              true,
+	"gamepb.plantpb.PlantService.WaterLand":              true,
+	"gamepb.plantpb.PlantService.WeedOut":                true,
+	"gamepb.plantpb.PlantService.Insecticide":            true,
+	"gamepb.plantpb.PlantService.PutWeeds":               true,
+	"gamepb.plantpb.PlantService.PutInsects":             true,
+	"gamepb.shoppb.ShopService.BuyGoods":                 true,
+	"gamepb.itempb.ItemService.Sell":                     true,
+	"gamepb.itempb.ItemService.Use":                      true,
+	"gamepb.itempb.ItemService.BatchUse":                 true,
+	"gamepb.taskpb.TaskService.ClaimTaskReward":          true,
+	"gamepb.taskpb.TaskService.BatchClaimTaskReward":     true,
+	"gamepb.friendpb.FriendService.AcceptFriends":        true,
+	"gamepb.friendpb.FriendService.RejectFriends":        true,
+	"gamepb.friendpb.FriendService.SetBlockApplications": true,
+	"gamepb.userpb.UserService.ReportArkClick":           true,
+}
+
+// 相同操作在该时间内只记录一次 (巡查循环会反复生成同样的计划)
+const DryRunDedupWindow = 10 * time.Minute
+
+// PlannedAction 模拟模式下被拦截的一次操作
+type PlannedAction struct {
+	Time     int64           `json:"time"`
+	Service  string          `json:"service"`
+	Method   string          `json:"method"`
+	Request  json.RawMessage `json:"request"`
+	Expected string          `json:"expected"` // 预期结果
+}
+
+// DryRunRecorder 模拟模式操作计划记录器
+type DryRunRecorder struct {
+	actions  []*PlannedAction
+	lastSeen map[string]time.Time // 操作内容 -> 上次记录时间
+	mu       sync.Mutex
+}
+
+var DryRun *DryRunRecorder
+
+func init() {
+	DryRun = &DryRunRecorder{
+		lastSeen: make(map[string]time.Time),
+	}
+}
+
+// IsDryRun 是否处于模拟模式
+func IsDryRun() bool {
+	return config.Current.DryRun
+}
+
+// sendGameRequest 发送游戏请求；改变游戏状态的请求写入操作日志，模拟模式下只记录计划，不发送
+func sendGameRequest(serviceName, methodName string, req proto.Message, resp proto.Message, timeout ...time.Duration) error {
+	mutating := mutatingMethods[serviceName+"."+methodName]
+	if IsDryRun() && mutating {
+		DryRun.record(serviceName, methodName, req)
+		return nil
+	}
+	Limiter.Wait()
+	err := network.Net.SendProtoMessage(serviceName, methodName, req, resp, timeout...)
+	if mutating {
+		Journal.Record(serviceName, methodName, req, resp, err)
+	}
+	return err
+}
+
+// SendGameRequest 供其他模块 (如登录后的邀请处理) 发送游戏请求，同样经过模拟模式拦截、操作日志和全局限速
+func SendGameRequest(serviceName, methodName string, req proto.Message, resp proto.Message, timeout ...time.Duration) error {
+	return sendGameRequest(serviceName, methodName, req, resp, timeout...)
+}
+
+// record 记录一次被拦截的操作
+func (dr *DryRunRecorder) record(serviceName, methodName string, req proto.Message) {
+	reqJSON, err := protojson.Marshal(req)
+	if err != nil {
+		reqJSON = []byte("{}")
+	}
+
+	key := serviceName + "." + methodName + string(reqJSON)
+	dr.mu.Lock()
+	if last, ok := dr.lastSeen[key]; ok && time.Since(last) < DryRunDedupWindow {
+		dr.mu.Unlock()
+		return
+	}
+	dr.lastSeen[key] = time.Now()
+	dr.mu.Unlock()
+
+	action := &PlannedAction{
+		Time:     utils.GetServerTimeSec(),
+		Service:  serviceName,
+		Method:   methodName,
+		Request:  reqJSON,
+		Expected: describeExpected(req),
+	}
+
+	dr.mu.Lock()
+	dr.actions = append(dr.actions, action)
+	dr.mu.Unlock()
+
+	utils.Log("模拟", fmt.Sprintf("[%s] %s", methodName, action.Expected))
+	dr.appendToFile(action)
+}
+
+// dryRunFilePath 模拟计划文件路径
+func dryRunFilePath() string {
+	return filepath.Join(LedgerDir, "dryrun.jsonl")
+}
+
+// appendToFile 追加计划到文件 (每行一条JSON)
+func (dr *DryRunRecorder) appendToFile(action *PlannedAction) {
+	if err := os.MkdirAll(LedgerDir, 0755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(dryRunFilePath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		utils.LogWarn("模拟", fmt.Sprintf("打开计划文件失败: %v", err))
+		return
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(action)
+	if err != nil {
+		return
+	}
+	f.Write(append(data, '\n'))
+}
+
+// GetPlannedActions 获取本次运行拦截的所有操作
+func (dr *DryRunRecorder) GetPlannedActions() []*PlannedAction {
+	dr.mu.Lock()
+	defer dr.mu.Unlock()
+	return append([]*PlannedAction(nil), dr.actions...)
+}
+
+// PrintSummary 按操作类型汇总本次运行的计划
+func (dr *DryRunRecorder) PrintSummary() {
+	actions := dr.GetPlannedActions()
+	if len(actions) == 0 {
+		return
+	}
+
+	counts := make(map[string]int)
+	var order []string
+	for _, action := range actions {
+		if counts[action.Method] == 0 {
+			order = append(order, action.Method)
+		}
+		counts[action.Method]++
+	}
+	parts := make([]string, 0, len(order))
+	for _, method := range order {
+		parts = append(parts, fmt.Sprintf("%s×%d", method, counts[method]))
+	}
+	utils.Log("模拟", fmt.Sprintf("共拦截 %d 个操作: %s (详见 %s)", len(actions), strings.Join(parts, " "), dryRunFilePath()))
+}
+
+// describeLands 描述操作的土地和目标农场
+func describeLands(landIds []int64, hostGID int64) string {
+	target := "自己"
+	if hostGID != 0 && hostGID != network.Net.GetUserState().GID {
+		target = Friend.GetFriendName(hostGID)
+	}
+	return fmt.Sprintf("%s农场 %d 块地 %v", target, len(landIds), landIds)
+}
+
+// expectedHarvest 根据最近的土地数据估算自家收获的果实和经验
+func expectedHarvest(landIds []int64) string {
+	fruit := make(map[string]int64)
+	exp := 0
+	for _, land := range Farm.GetLastLands() {
+		if land == nil || land.Plant == nil || !containsInt64(landIds, land.Id) {
+			continue
+		}
+		name := Config.GetPlantName(int(land.Plant.Id))
+		fruit[name] += land.Plant.LeftFruitNum
+		exp += Config.GetPlantExp(int(land.Plant.Id))
+	}
+	if len(fruit) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(fruit))
+	for name, count := range fruit {
+		parts = append(parts, fmt.Sprintf("%s x%d", name, count))
+	}
+	return fmt.Sprintf(", 预计获得 %s, 经验 %d", strings.Join(parts, " "), exp)
+}
+
+// describeItems 描述物品列表
+func describeItems(items []*corepb.Item) string {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != nil {
+			parts = append(parts, fmt.Sprintf("%s x%d", Config.GetItemName(int(item.Id)), item.Count))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// describeExpected 描述请求的预期结果
+func describeExpected(req proto.Message) string {
+	switch r := req.(type) {
+	case *plantpb.HarvestRequest:
+		text := "收获 " + describeLands(r.LandIds, r.HostGid)
+		if r.HostGid == network.Net.GetUserState().GID {
+			text += expectedHarvest(r.LandIds)
+		}
+		return text
+	case *plantpb.PlantRequest:
+		parts := make([]string, 0, len(r.Items))
+		for _, item := range r.Items {
+			parts = append(parts, fmt.Sprintf("%s → 土地%v", Config.GetPlantNameBySeedID(int(item.SeedId)), item.LandIds))
+		}
+		return "种植 " + strings.Join(parts, ", ")
+	case *plantpb.RemovePlantRequest:
+		return "铲除 " + describeLands(r.LandIds, 0)
+	case *plantpb.FertilizeRequest:
+		return fmt.Sprintf("施%s %s", Config.GetItemName(int(r.FertilizerId)), describeLands(r.LandIds, 0))
+	case *plantpb.WaterLandRequest:
+		return "浇水 " + describeLands(r.LandIds, r.HostGid)
+	case *plantpb.WeedOutRequest:
+		return "除草 " + describeLands(r.LandIds, r.HostGid)
+	case *plantpb.InsecticideRequest:
+		return "除虫 " + describeLands(r.LandIds, r.HostGid)
+	case *plantpb.PutWeedsRequest:
+		return "放草 " + describeLands(r.LandIds, r.HostGid)
+	case *plantpb.PutInsectsRequest:
+		return "放虫 " + describeLands(r.LandIds, r.HostGid)
+	case *shoppb.BuyGoodsRequest:
+		return fmt.Sprintf("购买商品#%d x%d, 预计花费 %d 金币", r.GoodsId, r.Num, r.Num*r.Price)
+	case *itempb.SellRequest:
+		gold := int64(0)
+		for _, item := range r.Items {
+			if info := Config.GetItemInfoByID(int(item.Id)); info != nil {
+				gold += info.Price * item.Count
+			}
+		}
+		return fmt.Sprintf("出售 %s, 预计获得 %d 金币", describeItems(r.Items), gold)
+	case *itempb.UseRequest:
+		text := fmt.Sprintf("使用 %s x%d", Config.GetItemName(int(r.ItemId)), r.Count)
+		if len(r.LandIds) > 0 {
+			text += fmt.Sprintf(" → 土地%v", r.LandIds)
+		}
+		return text
+	case *itempb.BatchUseRequest:
+		parts := make([]string, 0, len(r.Items))
+		for _, item := range r.Items {
+			parts = append(parts, fmt.Sprintf("%s x%d", Config.GetItemName(int(item.ItemId)), item.Count))
+		}
+		return "批量使用 " + strings.Join(parts, " ")
+	case *taskpb.ClaimTaskRewardRequest:
+		return fmt.Sprintf("领取任务#%d 奖励 (分享翻倍=%v)", r.Id, r.DoShared)
+	case *taskpb.BatchClaimTaskRewardRequest:
+		return fmt.Sprintf("批量领取任务%v 奖励 (分享翻倍=%v)", r.Ids, r.DoShared)
+	case *friendpb.AcceptFriendsRequest:
+		return fmt.Sprintf("同意 %d 个好友申请 %v", len(r.FriendGids), r.FriendGids)
+	case *friendpb.RejectFriendsRequest:
+		return fmt.Sprintf("拒绝 %d 个好友申请 %v", len(r.FriendGids), r.FriendGids)
+	case *friendpb.SetBlockApplicationsRequest:
+		return fmt.Sprintf("设置屏蔽好友申请=%v", r.Block)
+	case *userpb.ReportArkClickRequest:
+		return fmt.Sprintf("点击分享链接 (分享者 %d)", r.SharerId)
+	}
+	return ""
+}
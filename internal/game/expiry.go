@@ -0,0 +1,289 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/corepb"
+)
+
+// 临期物品处理方式
+const (
+	ExpiryActionUse  = "use"  // 过期前使用
+	ExpiryActionSell = "sell" // 过期前出售
+	ExpiryActionWarn = "warn" // 只提醒
+)
+
+// 配置: 临期物品检查间隔
+const ExpiryCheckInterval = 10 * time.Minute
+
+// ExpiringItem 临期物品
+type ExpiringItem struct {
+	Item       *corepb.Item
+	Info       *ItemInfo
+	Name       string
+	ExpireTime int64  // 过期时间(秒)
+	Action     string // 处理方式
+}
+
+// key 物品唯一键 (同一物品可能有多条不同过期时间的记录)
+func (ei *ExpiringItem) key() string {
+	return fmt.Sprintf("%d:%d:%d", ei.Item.Id, ei.Item.Uid, ei.ExpireTime)
+}
+
+// ExpiryTracker 临期物品追踪器
+type ExpiryTracker struct {
+	isChecking    bool
+	loopRunning   bool
+	networkEvents *network.EventEmitter
+	scheduled     map[string]*time.Timer // 已安排处理的物品
+	warnings      []*ExpiringItem        // 当前需要提醒的贵重物品
+	mu            sync.RWMutex
+}
+
+var Expiry *ExpiryTracker
+
+func init() {
+	Expiry = &ExpiryTracker{
+		networkEvents: network.Net.GetEvents(),
+		scheduled:     make(map[string]*time.Timer),
+	}
+}
+
+// policyFor 获取物品类型对应的处理方式
+func (et *ExpiryTracker) policyFor(info *ItemInfo) string {
+	if info == nil {
+		return ExpiryActionWarn
+	}
+	if action, ok := config.Current.ExpiryPolicies[info.Type]; ok {
+		return action
+	}
+	return ExpiryActionWarn
+}
+
+// isValuable 检查物品是否贵重 (过期前需要提醒)
+func (et *ExpiryTracker) isValuable(info *ItemInfo) bool {
+	return info != nil && info.Rarity >= config.Current.ExpiryWarnRarity
+}
+
+// AnalyzeExpiringItems 找出背包中在提醒时间内过期的物品，按过期时间排序
+func (et *ExpiryTracker) AnalyzeExpiringItems(items []*corepb.Item, nowSec int64) []*ExpiringItem {
+	warnLead := int64(config.Current.ExpiryWarnLead.Seconds())
+
+	var result []*ExpiringItem
+	for _, item := range items {
+		if item == nil || item.Count <= 0 {
+			continue
+		}
+		expireTime := utils.ToTimeSec(item.ExpireTime)
+		if expireTime <= 0 || expireTime <= nowSec || expireTime-nowSec > warnLead {
+			continue
+		}
+		info := Config.GetItemInfoByID(int(item.Id))
+		result = append(result, &ExpiringItem{
+			Item:       item,
+			Info:       info,
+			Name:       Config.GetItemName(int(item.Id)),
+			ExpireTime: expireTime,
+			Action:     et.policyFor(info),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ExpireTime < result[j].ExpireTime
+	})
+	return result
+}
+
+// CheckExpiringItems 检查背包中的临期物品并安排处理
+func (et *ExpiryTracker) CheckExpiringItems() {
+	if et.isChecking {
+		return
+	}
+	et.isChecking = true
+	defer func() { et.isChecking = false }()
+
+	bagReply, err := Warehouse.GetBag()
+	if err != nil {
+		utils.LogWarn("临期物品", fmt.Sprintf("获取背包失败: %v", err))
+		return
+	}
+
+	nowSec := utils.GetServerTimeSec()
+	expiring := et.AnalyzeExpiringItems(Warehouse.getBagItems(bagReply), nowSec)
+	actLead := int64(config.Current.ExpiryActLead.Seconds())
+
+	var warnings []*ExpiringItem
+	for _, ei := range expiring {
+		if ei.Action == ExpiryActionUse || ei.Action == ExpiryActionSell {
+			et.schedule(ei, ei.ExpireTime-actLead-nowSec)
+			continue
+		}
+		if et.isValuable(ei.Info) {
+			warnings = append(warnings, ei)
+		}
+	}
+
+	et.mu.Lock()
+	et.warnings = warnings
+	et.mu.Unlock()
+
+	for _, ei := range warnings {
+		utils.LogWarn("临期物品", fmt.Sprintf("%s x%d 将在 %s 后过期，尚未使用",
+			ei.Name, ei.Item.Count, FormatGrowTime(int(ei.ExpireTime-nowSec))))
+	}
+	et.networkEvents.Emit("expiryWarning", et.WarningSummary())
+}
+
+// schedule 安排在 delaySec 秒后处理临期物品 (已安排的不重复安排)
+func (et *ExpiryTracker) schedule(ei *ExpiringItem, delaySec int64) {
+	key := ei.key()
+
+	et.mu.Lock()
+	defer et.mu.Unlock()
+
+	if _, ok := et.scheduled[key]; ok {
+		return
+	}
+	if delaySec < 0 {
+		delaySec = 0
+	}
+	et.scheduled[key] = time.AfterFunc(time.Duration(delaySec)*time.Second, func() {
+		et.handle(ei)
+		et.mu.Lock()
+		delete(et.scheduled, key)
+		et.mu.Unlock()
+	})
+	if delaySec > 0 {
+		utils.Log("临期物品", fmt.Sprintf("%s x%d 已安排在 %s 后%s",
+			ei.Name, ei.Item.Count, FormatGrowTime(int(delaySec)), expiryActionName(ei.Action)))
+	}
+}
+
+// currentBagItem 重新读取背包中该临期物品的当前记录 (已用完或已过期时返回nil)
+func (et *ExpiryTracker) currentBagItem(ei *ExpiringItem) (*corepb.Item, error) {
+	bagReply, err := Warehouse.GetBag()
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range Warehouse.getBagItems(bagReply) {
+		if item != nil && item.Id == ei.Item.Id && item.Uid == ei.Item.Uid &&
+			utils.ToTimeSec(item.ExpireTime) == ei.ExpireTime && item.Count > 0 {
+			return item, nil
+		}
+	}
+	return nil, nil
+}
+
+// handle 处理单个临期物品 (按处理时背包中的数量)
+func (et *ExpiryTracker) handle(ei *ExpiringItem) {
+	current, err := et.currentBagItem(ei)
+	if err != nil {
+		utils.LogWarn("临期物品", fmt.Sprintf("获取背包失败, 无法处理 %s: %v", ei.Name, err))
+		return
+	}
+	if current == nil {
+		return // 已经用完或卖掉
+	}
+
+	switch ei.Action {
+	case ExpiryActionUse:
+		if Consumable.isLandTargetItem(ei.Info) {
+			used := Consumable.UseLandItem(&UsableItem{Info: ei.Info, Count: current.Count})
+			utils.Log("临期物品", fmt.Sprintf("过期前使用 %s x%d", ei.Name, used))
+			return
+		}
+		reply, err := Consumable.UseItem(current.Id, current.Count, nil)
+		if err != nil {
+			utils.LogWarn("临期物品", fmt.Sprintf("使用 %s 失败: %v", ei.Name, err))
+			return
+		}
+		utils.Log("临期物品", fmt.Sprintf("过期前使用 %s x%d → %s", ei.Name, current.Count, Task.formatRewardItems(reply.Items)))
+
+	case ExpiryActionSell:
+		item := Mutation.SellableItem(current)
+		if item == nil {
+			utils.LogWarn("临期物品", fmt.Sprintf("%s 是变异作物的果实，不自动出售", ei.Name))
+			return
+		}
+		reply, err := Warehouse.SellItems([]*corepb.Item{item})
+		if err != nil {
+			utils.LogWarn("临期物品", fmt.Sprintf("出售 %s 失败: %v", ei.Name, err))
+			return
+		}
+		utils.Log("临期物品", fmt.Sprintf("过期前出售 %s x%d，获得 %d 金币", ei.Name, item.Count, Warehouse.extractGold(reply)))
+	}
+}
+
+// expiryActionName 处理方式名称
+func expiryActionName(action string) string {
+	switch action {
+	case ExpiryActionUse:
+		return "使用"
+	case ExpiryActionSell:
+		return "出售"
+	default:
+		return "提醒"
+	}
+}
+
+// WarningSummary 临期贵重物品摘要 (用于状态栏，无提醒时为空)
+func (et *ExpiryTracker) WarningSummary() string {
+	et.mu.RLock()
+	defer et.mu.RUnlock()
+
+	if len(et.warnings) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(et.warnings))
+	for _, ei := range et.warnings {
+		names = append(names, ei.Name)
+	}
+	return fmt.Sprintf("临期: %s", strings.Join(names, "/"))
+}
+
+// StartExpiryLoop 启动临期物品检查循环
+func (et *ExpiryTracker) StartExpiryLoop() {
+	if et.loopRunning {
+		return
+	}
+
+	et.loopRunning = true
+	utils.Log("临期物品", "临期物品检查循环已启动")
+
+	// 立即执行一次
+	go et.CheckExpiringItems()
+
+	// 定时器循环
+	go func() {
+		for et.loopRunning {
+			time.Sleep(ExpiryCheckInterval)
+
+			if !et.loopRunning {
+				break
+			}
+
+			et.CheckExpiringItems()
+		}
+	}()
+}
+
+// StopExpiryLoop 停止临期物品检查循环，取消所有已安排的处理
+func (et *ExpiryTracker) StopExpiryLoop() {
+	et.loopRunning = false
+
+	et.mu.Lock()
+	for key, timer := range et.scheduled {
+		timer.Stop()
+		delete(et.scheduled, key)
+	}
+	et.mu.Unlock()
+
+	utils.Log("临期物品", "临期物品检查循环已停止")
+}
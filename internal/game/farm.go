@@ -26,7 +26,9 @@ type FarmManager struct {
 	checkTimer     *time.Timer
 	loopRunning    bool
 	networkEvents  *network.EventEmitter
-	operationLimits map[int32]*plantpb.OperationLimit
+	lastLands      []*plantpb.LandInfo // 最近一次巡查的土地数据
+	guard          *HarvestGuard       // 防偷收获守卫
+	checkNow       chan struct{}       // 通知巡查循环立即巡查
 	mu             sync.RWMutex
 }
 
@@ -34,9 +36,10 @@ var Farm *FarmManager
 
 func init() {
 	Farm = &FarmManager{
-		isFirstCheck:    true,
-		networkEvents:   network.Net.GetEvents(),
-		operationLimits: make(map[int32]*plantpb.OperationLimit),
+		isFirstCheck:  true,
+		networkEvents: network.Net.GetEvents(),
+		guard:         newHarvestGuard(),
+		checkNow:      make(chan struct{}, 1),
 	}
 }
 
@@ -45,21 +48,13 @@ func (fm *FarmManager) GetAllLands() (*plantpb.AllLandsReply, error) {
 	req := &plantpb.AllLandsRequest{}
 	resp := &plantpb.AllLandsReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "AllLands", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "AllLands", req, resp, 10*time.Second)
 	if err != nil {
 		return nil, err
 	}
 	
 	// 更新操作限制
-	if resp.OperationLimits != nil {
-		fm.mu.Lock()
-		for _, limit := range resp.OperationLimits {
-			if limit != nil {
-				fm.operationLimits[int32(limit.Id)] = limit
-			}
-		}
-		fm.mu.Unlock()
-	}
+	OpLimits.Update(resp.OperationLimits)
 	
 	return resp, nil
 }
@@ -74,7 +69,7 @@ func (fm *FarmManager) Harvest(landIds []int64) (*plantpb.HarvestReply, error) {
 	}
 	resp := &plantpb.HarvestReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "Harvest", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "Harvest", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -86,7 +81,7 @@ func (fm *FarmManager) WaterLand(landIds []int64, hostGID int64) (*plantpb.Water
 	}
 	resp := &plantpb.WaterLandReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "WaterLand", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "WaterLand", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -98,7 +93,7 @@ func (fm *FarmManager) WeedOut(landIds []int64, hostGID int64) (*plantpb.WeedOut
 	}
 	resp := &plantpb.WeedOutReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "WeedOut", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "WeedOut", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -110,7 +105,7 @@ func (fm *FarmManager) Insecticide(landIds []int64, hostGID int64) (*plantpb.Ins
 	}
 	resp := &plantpb.InsecticideReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "Insecticide", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "Insecticide", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -124,7 +119,7 @@ func (fm *FarmManager) Fertilize(landIds []int64, fertilizerID int64) (int, erro
 		}
 		resp := &plantpb.FertilizeReply{}
 		
-		err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "Fertilize", req, resp, 5*time.Second)
+		err := sendGameRequest("gamepb.plantpb.PlantService", "Fertilize", req, resp, 5*time.Second)
 		if err != nil {
 			// 施肥失败（可能肥料不足），停止继续
 			break
@@ -145,36 +140,69 @@ func (fm *FarmManager) RemovePlant(landIds []int64) (*plantpb.RemovePlantReply,
 	}
 	resp := &plantpb.RemovePlantReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "RemovePlant", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "RemovePlant", req, resp, 10*time.Second)
 	return resp, err
 }
 
-// PlantSeeds 种植
-func (fm *FarmManager) PlantSeeds(seedID int64, landIds []int64) (int, error) {
-	successCount := 0
+// PlantSeeds 种植，返回种下作物的土地和被大作物占用的副地
+// 大作物只在可共享的土地上自动占用副地，被占用的土地不再单独种植; 一块都没种上时返回最后一次的错误
+func (fm *FarmManager) PlantSeeds(seedID int64, landIds []int64) ([]int64, []int64, error) {
+	largeCrop := seedLandSize(seedID) > 1
+	lands := fm.landInfoMap()
+	
+	var planted, slaves []int64
+	var lastErr error
+	occupied := make(map[int64]bool)
 	for _, landId := range landIds {
+		if occupied[landId] {
+			continue
+		}
 		req := &plantpb.PlantRequest{
 			Items: []*plantpb.PlantItem{
 				{
-					SeedId:  seedID,
-					LandIds: []int64{landId},
+					SeedId:    seedID,
+					LandIds:   []int64{landId},
+					AutoSlave: largeCrop && canShareLand(lands[landId]),
 				},
 			},
 		}
 		resp := &plantpb.PlantReply{}
 		
-		err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "Plant", req, resp, 5*time.Second)
+		err := sendGameRequest("gamepb.plantpb.PlantService", "Plant", req, resp, 5*time.Second)
 		if err != nil {
 			utils.LogWarn("种植", fmt.Sprintf("土地#%d 失败: %v", landId, err))
+			lastErr = err
 			continue
 		}
-		successCount++
+		planted = append(planted, landId)
+		for _, slaveID := range slaveIDsFromReply(resp.Land) {
+			if !occupied[slaveID] {
+				occupied[slaveID] = true
+				slaves = append(slaves, slaveID)
+			}
+		}
 		
 		if len(landIds) > 1 {
 			time.Sleep(50 * time.Millisecond) // 50ms间隔
 		}
 	}
-	return successCount, nil
+	if len(planted) == 0 && lastErr != nil {
+		return nil, slaves, lastErr
+	}
+	return planted, slaves, nil
+}
+
+// landInfoMap 最近一次巡查的土地 (土地ID -> 土地信息)
+func (fm *FarmManager) landInfoMap() map[int64]*plantpb.LandInfo {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	result := make(map[int64]*plantpb.LandInfo, len(fm.lastLands))
+	for _, land := range fm.lastLands {
+		if land != nil {
+			result[land.Id] = land
+		}
+	}
+	return result
 }
 
 // GetShopInfo 获取商店信息
@@ -184,12 +212,16 @@ func (fm *FarmManager) GetShopInfo(shopID int64) (*shoppb.ShopInfoReply, error)
 	}
 	resp := &shoppb.ShopInfoReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.shoppb.ShopService", "ShopInfo", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.shoppb.ShopService", "ShopInfo", req, resp, 10*time.Second)
 	return resp, err
 }
 
-// BuyGoods 购买商品
-func (fm *FarmManager) BuyGoods(goodsID int64, num int64, price int64) (*shoppb.BuyGoodsReply, error) {
+// BuyGoods 购买商品 (需通过对应类别的金币预算)
+func (fm *FarmManager) BuyGoods(category string, goodsID int64, num int64, price int64) (*shoppb.BuyGoodsReply, error) {
+	if err := Budget.Approve(category, num*price); err != nil {
+		return nil, err
+	}
+	
 	req := &shoppb.BuyGoodsRequest{
 		GoodsId: goodsID,
 		Num:     num,
@@ -197,7 +229,10 @@ func (fm *FarmManager) BuyGoods(goodsID int64, num int64, price int64) (*shoppb.
 	}
 	resp := &shoppb.BuyGoodsReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.shoppb.ShopService", "BuyGoods", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.shoppb.ShopService", "BuyGoods", req, resp, 10*time.Second)
+	if err == nil {
+		Budget.Spend(category, num*price)
+	}
 	return resp, err
 }
 
@@ -211,6 +246,7 @@ type LandStatus struct {
 	Empty           []int64
 	Dead            []int64
 	HarvestableInfo []HarvestablePlant
+	Groups          map[int64]*LandGroup // 共享土地组 (主地ID -> 土地组)
 }
 
 // HarvestablePlant 可收获植物信息
@@ -219,6 +255,8 @@ type HarvestablePlant struct {
 	PlantID  int64
 	Name     string
 	Exp      int
+	FruitID  int64
+	FruitNum int64
 }
 
 // AnalyzeLands 分析土地状态
@@ -232,11 +270,13 @@ func (fm *FarmManager) AnalyzeLands(lands []*plantpb.LandInfo) *LandStatus {
 		Empty:           []int64{},
 		Dead:            []int64{},
 		HarvestableInfo: []HarvestablePlant{},
+		Groups:          BuildLandGroups(lands),
 	}
 	
 	nowSec := utils.GetServerTimeSec()
 	
-	for _, land := range lands {
+	// 副地跟随主地，只分析主地和独立土地
+	for _, land := range MasterLands(lands) {
 		if land == nil || !land.Unlocked {
 			continue
 		}
@@ -267,10 +307,12 @@ func (fm *FarmManager) AnalyzeLands(lands []*plantpb.LandInfo) *LandStatus {
 			plantName := Config.GetPlantName(int(plantID))
 			plantExp := Config.GetPlantExp(int(plantID))
 			result.HarvestableInfo = append(result.HarvestableInfo, HarvestablePlant{
-				LandID:  landID,
-				PlantID: plantID,
-				Name:    plantName,
-				Exp:     plantExp,
+				LandID:   landID,
+				PlantID:  plantID,
+				Name:     plantName,
+				Exp:      plantExp,
+				FruitID:  plant.FruitId,
+				FruitNum: plant.LeftFruitNum,
 			})
 			
 		default:
@@ -354,6 +396,13 @@ func (fm *FarmManager) CheckFarm() {
 		return
 	}
 	
+	// 作息: 暂停时不巡查, 只收获时只做收获和防偷, 不照料/购买/种植
+	mode := Schedule.ModeFor(ModuleFarm)
+	if mode == ModePaused {
+		return
+	}
+	harvestOnly := mode == ModeHarvestOnly
+	
 	landsReply, err := fm.GetAllLands()
 	if err != nil {
 		utils.LogWarn("农场", fmt.Sprintf("获取土地失败: %v", err))
@@ -365,13 +414,18 @@ func (fm *FarmManager) CheckFarm() {
 		return
 	}
 	
+	fm.mu.Lock()
+	fm.lastLands = landsReply.Lands
+	fm.mu.Unlock()
+	
+	// 共享土地的副地和主地是同一株作物，只看主地
+	masterLands := MasterLands(landsReply.Lands)
+	Theft.ObserveLands(masterLands)
+	Mutation.ObserveLands(masterLands)
+	fm.ArmHarvestGuard(masterLands)
+	
 	status := fm.AnalyzeLands(landsReply.Lands)
-	unlockedCount := 0
-	for _, land := range landsReply.Lands {
-		if land != nil && land.Unlocked {
-			unlockedCount++
-		}
-	}
+	unlockedCount := CountPlots(landsReply.Lands)
 	
 	fm.isFirstCheck = false
 	
@@ -396,6 +450,9 @@ func (fm *FarmManager) CheckFarm() {
 		statusParts = append(statusParts, fmt.Sprintf("空:%d", len(status.Empty)))
 	}
 	statusParts = append(statusParts, fmt.Sprintf("长:%d", len(status.Growing)))
+	if len(status.Groups) > 0 {
+		statusParts = append(statusParts, fmt.Sprintf("组:%s", joinStrings(FormatLandGroups(status.Groups), ",")))
+	}
 	
 	hasWork := len(status.Harvestable) > 0 || len(status.NeedWeed) > 0 || 
 	           len(status.NeedBug) > 0 || len(status.NeedWater) > 0 || 
@@ -406,10 +463,11 @@ func (fm *FarmManager) CheckFarm() {
 	// 并行执行除草、除虫、浇水
 	var wg sync.WaitGroup
 	
-	if len(status.NeedWeed) > 0 {
+	if len(status.NeedWeed) > 0 && !harvestOnly {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			Reciprocity.MarkOwnCare(status.NeedWeed)
 			if _, err := fm.WeedOut(status.NeedWeed, state.GID); err != nil {
 				utils.LogWarn("除草", err.Error())
 			} else {
@@ -418,10 +476,11 @@ func (fm *FarmManager) CheckFarm() {
 		}()
 	}
 	
-	if len(status.NeedBug) > 0 {
+	if len(status.NeedBug) > 0 && !harvestOnly {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			Reciprocity.MarkOwnCare(status.NeedBug)
 			if _, err := fm.Insecticide(status.NeedBug, state.GID); err != nil {
 				utils.LogWarn("除虫", err.Error())
 			} else {
@@ -430,10 +489,11 @@ func (fm *FarmManager) CheckFarm() {
 		}()
 	}
 	
-	if len(status.NeedWater) > 0 {
+	if len(status.NeedWater) > 0 && !harvestOnly {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
+			Reciprocity.MarkOwnCare(status.NeedWater)
 			if _, err := fm.WaterLand(status.NeedWater, state.GID); err != nil {
 				utils.LogWarn("浇水", err.Error())
 			} else {
@@ -447,17 +507,28 @@ func (fm *FarmManager) CheckFarm() {
 	// 收获（支持延时）
 	harvestedLandIds := []int64{}
 	if len(status.Harvestable) > 0 {
-		// 检查是否需要延时收获
-		if config.Current.HarvestDelay > 0 {
-			utils.Log("收获", fmt.Sprintf("等待 %v 后收获...", config.Current.HarvestDelay))
-			time.Sleep(config.Current.HarvestDelay)
+		toHarvest := status.Harvestable
+		
+		// 变异作物优先单独收获 (不参与延时)
+		if config.Current.HarvestMutantsFirst {
+			mutants, others := Mutation.SplitMutantLands(toHarvest)
+			if len(mutants) > 0 {
+				harvestedLandIds = append(harvestedLandIds, fm.harvestOwnLands(mutants, status.HarvestableInfo)...)
+				toHarvest = others
+			}
 		}
-
-		if _, err := fm.Harvest(status.Harvestable); err != nil {
-			utils.LogWarn("收获", err.Error())
-		} else {
-			actions = append(actions, fmt.Sprintf("收获%d", len(status.Harvestable)))
-			harvestedLandIds = append(harvestedLandIds, status.Harvestable...)
+		
+		if len(toHarvest) > 0 {
+			// 检查是否需要延时收获 (防偷模式下立即收获)
+			if config.Current.HarvestDelay > 0 && !config.Current.HarvestGuard {
+				utils.Log("收获", fmt.Sprintf("等待 %v 后收获...", config.Current.HarvestDelay))
+				time.Sleep(config.Current.HarvestDelay)
+			}
+			harvestedLandIds = append(harvestedLandIds, fm.harvestOwnLands(toHarvest, status.HarvestableInfo)...)
+		}
+		
+		if len(harvestedLandIds) > 0 {
+			actions = append(actions, fmt.Sprintf("收获%d", len(harvestedLandIds)))
 		}
 	}
 	
@@ -465,7 +536,7 @@ func (fm *FarmManager) CheckFarm() {
 	allDeadLands := append(status.Dead, harvestedLandIds...)
 	allEmptyLands := status.Empty
 	
-	if len(allDeadLands) > 0 || len(allEmptyLands) > 0 {
+	if (len(allDeadLands) > 0 || len(allEmptyLands) > 0) && !harvestOnly {
 		if err := fm.AutoPlantEmptyLands(allDeadLands, allEmptyLands, unlockedCount); err != nil {
 			utils.LogWarn("种植", err.Error())
 		} else {
@@ -483,14 +554,37 @@ func (fm *FarmManager) CheckFarm() {
 	}
 }
 
+// harvestOwnLands 收获自家土地并记账，返回成功收获的土地
+func (fm *FarmManager) harvestOwnLands(landIds []int64, infos []HarvestablePlant) []int64 {
+	reply, err := fm.Harvest(landIds)
+	if err != nil {
+		utils.LogWarn("收获", err.Error())
+		return nil
+	}
+	
+	var harvestedInfos []HarvestablePlant
+	for _, info := range infos {
+		if containsInt64(landIds, info.LandID) {
+			harvestedInfos = append(harvestedInfos, info)
+		}
+	}
+	lands := harvestedLandsFromInfo(harvestedInfos)
+	Ledger.RecordHarvest(reply, lands, HarvestSourceOwn, 0, "")
+	Mutation.RecordHarvest(reply, lands)
+	
+	return landIds
+}
+
 // AutoPlantEmptyLands 自动种植空地
 func (fm *FarmManager) AutoPlantEmptyLands(deadLandIds, emptyLandIds []int64, unlockedCount int) error {
-	state := network.Net.GetUserState()
-	
 	// 1. 铲除枯死作物
 	landsToPlant := make([]int64, len(emptyLandIds))
 	copy(landsToPlant, emptyLandIds)
 	
+	if config.Current.ProtectMutants {
+		deadLandIds = Mutation.FilterRemovable(deadLandIds)
+	}
+	
 	if len(deadLandIds) > 0 {
 		if _, err := fm.RemovePlant(deadLandIds); err != nil {
 			utils.LogWarn("铲除", fmt.Sprintf("批量铲除失败: %v", err))
@@ -504,12 +598,29 @@ func (fm *FarmManager) AutoPlantEmptyLands(deadLandIds, emptyLandIds []int64, un
 		return nil
 	}
 	
-	// 2. 查询最佳种子
-	bestSeed, err := fm.FindBestSeed(unlockedCount)
-	if err != nil {
-		return fmt.Errorf("查询种子失败: %w", err)
+	// 2. 查询最佳种子，作为背包种子经验效率的参考 (查询失败时不限制背包种子)
+	bestSeed, shopErr := fm.FindBestSeed(unlockedCount)
+	bestExpPerHour := 0.0
+	if shopErr == nil && bestSeed != nil {
+		bestExpPerHour = seedExpPerHour(Config.GetPlantBySeedID(int(bestSeed.SeedId)))
+	}
+	
+	// 3. 优先使用背包中的种子，只购买差额
+	plantedLands := []int64{}
+	if config.Current.UseBagSeeds {
+		plantedLands, landsToPlant = fm.plantFromBag(landsToPlant, bestExpPerHour)
+	}
+	
+	if len(landsToPlant) == 0 {
+		fm.fertilizeLands(plantedLands)
+		return nil
+	}
+	if shopErr != nil {
+		fm.fertilizeLands(plantedLands)
+		return fmt.Errorf("查询种子失败: %w", shopErr)
 	}
 	if bestSeed == nil {
+		fm.fertilizeLands(plantedLands)
 		return fmt.Errorf("没有可购买的种子")
 	}
 	
@@ -523,23 +634,28 @@ func (fm *FarmManager) AutoPlantEmptyLands(deadLandIds, emptyLandIds []int64, un
 	utils.Log("商店", fmt.Sprintf("最佳种子: %s (%d) 价格=%d金币%s",
 		seedName, bestSeed.SeedId, bestSeed.Price, growTimeStr))
 	
-	// 3. 购买种子
-	needCount := len(landsToPlant)
+	// 4. 购买种子 (大作物一颗种子占用多块地)
+	lands := fm.landInfoMap()
+	needCount := int(seedsForLands(bestSeed.SeedId, landsToPlant, lands))
 	totalCost := bestSeed.Price * int64(needCount)
 	
-	if totalCost > state.Gold {
-		utils.LogWarn("商店", fmt.Sprintf("金币不足! 需要 %d 金币, 当前 %d 金币", totalCost, state.Gold))
-		canBuy := int(state.Gold / bestSeed.Price)
+	canBuy := Budget.Allow(BudgetSeeds, bestSeed.Price, needCount)
+	if canBuy < needCount {
+		utils.LogWarn("商店", fmt.Sprintf("种子预算不足! 需要 %d 金币, 可用 %d 金币 (余额 %d)",
+			totalCost, Budget.Available(BudgetSeeds), Budget.Balance()))
 		if canBuy <= 0 {
-			return fmt.Errorf("金币不足")
+			fm.fertilizeLands(plantedLands)
+			return fmt.Errorf("金币预算不足")
 		}
-		landsToPlant = landsToPlant[:canBuy]
-		utils.Log("商店", fmt.Sprintf("金币有限，只种 %d 块地", canBuy))
+		landsToPlant = landsToPlant[:coverLands(bestSeed.SeedId, landsToPlant, lands, int64(canBuy))]
+		needCount = canBuy
+		utils.Log("商店", fmt.Sprintf("预算有限，只种 %d 块地", len(landsToPlant)))
 	}
 	
 	actualSeedId := bestSeed.SeedId
-	buyReply, err := fm.BuyGoods(bestSeed.GoodsId, int64(len(landsToPlant)), bestSeed.Price)
+	buyReply, err := fm.BuyGoods(BudgetSeeds, bestSeed.GoodsId, int64(needCount), bestSeed.Price)
 	if err != nil {
+		fm.fertilizeLands(plantedLands)
 		return fmt.Errorf("购买失败: %w", err)
 	}
 	
@@ -552,27 +668,39 @@ func (fm *FarmManager) AutoPlantEmptyLands(deadLandIds, emptyLandIds []int64, un
 	
 	boughtName := Config.GetPlantNameBySeedID(int(actualSeedId))
 	utils.Log("购买", fmt.Sprintf("已购买 %s种子 x%d, 花费 %d 金币",
-		boughtName, len(landsToPlant), bestSeed.Price*int64(len(landsToPlant))))
+		boughtName, needCount, bestSeed.Price*int64(needCount)))
+	utils.Log("预算", Budget.Summary())
 	
-	// 4. 种植
-	planted, err := fm.PlantSeeds(actualSeedId, landsToPlant)
+	// 5. 种植
+	planted, slaves, err := fm.PlantSeeds(actualSeedId, landsToPlant)
 	if err != nil {
+		fm.fertilizeLands(plantedLands)
 		return fmt.Errorf("种植失败: %w", err)
 	}
-	utils.Log("种植", fmt.Sprintf("已在 %d 块地种植", planted))
-	
-	// 5. 施肥
-	if planted > 0 {
-		plantedLands := landsToPlant[:planted]
-		fertilized, _ := fm.Fertilize(plantedLands, NormalFertilizerID)
-		if fertilized > 0 {
-			utils.Log("施肥", fmt.Sprintf("已为 %d/%d 块地施肥", fertilized, len(plantedLands)))
-		}
+	if len(slaves) > 0 {
+		utils.Log("种植", fmt.Sprintf("已在 %d 块地种植 (另占用 %d 块副地)", len(planted), len(slaves)))
+	} else {
+		utils.Log("种植", fmt.Sprintf("已在 %d 块地种植", len(planted)))
 	}
 	
+	// 6. 施肥 (只对实际种下的主地)
+	plantedLands = append(plantedLands, planted...)
+	fm.fertilizeLands(plantedLands)
+	
 	return nil
 }
 
+// fertilizeLands 为刚种下的土地施普通肥
+func (fm *FarmManager) fertilizeLands(landIds []int64) {
+	if len(landIds) == 0 {
+		return
+	}
+	fertilized, _ := fm.Fertilize(landIds, NormalFertilizerID)
+	if fertilized > 0 {
+		utils.Log("施肥", fmt.Sprintf("已为 %d/%d 块地施肥", fertilized, len(landIds)))
+	}
+}
+
 // SeedInfo 种子信息
 type SeedInfo struct {
 	Goods       *shoppb.GoodsInfo
@@ -649,6 +777,22 @@ func (fm *FarmManager) FindBestSeed(landsCount int) (*SeedInfo, error) {
 		return best, nil
 	}
 	
+	// 设置了等级目标时按目标规划选择种子
+	if seedID := Planner.PlannedSeedID(state.Level, state.Exp, landsCount); seedID > 0 {
+		for _, s := range available {
+			if s.SeedId == seedID {
+				utils.Log("种植", fmt.Sprintf("按等级目标规划种植: %s (目标 Lv%d)",
+					Config.GetPlantNameBySeedID(int(seedID)), Planner.TargetLevel))
+				return s, nil
+			}
+		}
+	}
+	
+	// 有离线时间段时，选择成熟时间最适合下次补种时间的种子
+	if s := fm.pickSeedForGap(available); s != nil {
+		return s, nil
+	}
+	
 	// 使用经验效率算法选择最佳种子
 	// 获取当前等级和土地数量的最佳种子推荐
 	rec := tools.GetPlantingRecommendation(state.Level, landsCount)
@@ -696,6 +840,13 @@ func (fm *FarmManager) FindBestSeed(landsCount int) (*SeedInfo, error) {
 	}
 }
 
+// GetLastLands 获取最近一次巡查的土地数据
+func (fm *FarmManager) GetLastLands() []*plantpb.LandInfo {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	return fm.lastLands
+}
+
 // StartFarmCheckLoop 启动农场巡查循环
 func (fm *FarmManager) StartFarmCheckLoop() {
 	if fm.loopRunning {
@@ -726,7 +877,18 @@ func (fm *FarmManager) farmCheckLoop() {
 		if !fm.loopRunning {
 			break
 		}
-		time.Sleep(config.Current.FarmCheckInterval)
+		select {
+		case <-time.After(Schedule.Interval(ModuleFarm, config.Current.FarmCheckInterval)):
+		case <-fm.checkNow:
+		}
+	}
+}
+
+// RequestCheck 通知巡查循环立即巡查 (已有待处理的通知时忽略)
+func (fm *FarmManager) RequestCheck() {
+	select {
+	case fm.checkNow <- struct{}{}:
+	default:
 	}
 }
 
@@ -736,6 +898,7 @@ func (fm *FarmManager) StopFarmCheckLoop() {
 	if fm.checkTimer != nil {
 		fm.checkTimer.Stop()
 	}
+	fm.stopHarvestGuard()
 	fm.networkEvents.Off("landsChanged", nil)
 }
 
@@ -2,6 +2,7 @@ package game
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -14,16 +15,16 @@ import (
 	"gofarm/internal/utils"
 )
 
-// 操作类型ID常量
+// 操作类型 (服务器的限制ID由 OpLimits 根据实际操作学习)
 const (
-	OpHarvest     = 10001 // 收获
-	OpRemovePlant = 10002 // 铲除
-	OpPutWeeds    = 10003 // 放草
-	OpPutInsects  = 10004 // 放虫
-	OpWeedOut     = 10005 // 帮好友除草
-	OpInsecticide = 10006 // 帮好友除虫
-	OpWaterLand   = 10007 // 帮好友浇水
-	OpSteal       = 10008 // 偷菜
+	OpHarvest     = iota + 1 // 收获
+	OpRemovePlant            // 铲除
+	OpPutWeeds               // 放草
+	OpPutInsects             // 放虫
+	OpWeedOut                // 帮好友除草
+	OpInsecticide            // 帮好友除虫
+	OpWaterLand              // 帮好友浇水
+	OpSteal                  // 偷菜
 )
 
 // 操作类型名称映射
@@ -44,11 +45,13 @@ type FriendManager struct {
 	isFirstFriendCheck bool
 	friendCheckTimer  *time.Timer
 	friendLoopRunning bool
-	lastResetDate     string
 	networkEvents     *network.EventEmitter
-	operationLimits   map[int32]*plantpb.OperationLimit
 	expTracker        map[int32]int64 // opId -> 帮助前的 dayExpTimes
 	expExhausted      map[int32]bool  // 经验已耗尽的操作类型
+	friendNames       map[int64]string // GID -> 好友昵称
+	visitSlots        chan struct{}    // 同时拜访的农场数 (巡查和定时偷菜共用)
+	visitOnce         sync.Once
+	visiting          map[int64]bool   // 正在拜访的好友 (同一农场不同时进入)
 	mu                sync.RWMutex
 }
 
@@ -63,32 +66,21 @@ const EnablePutBadThings = false
 func init() {
 	Friend = &FriendManager{
 		isFirstFriendCheck: true,
-		lastResetDate:      getLocalDateKey(),
 		networkEvents:      network.Net.GetEvents(),
-		operationLimits:    make(map[int32]*plantpb.OperationLimit),
 		expTracker:         make(map[int32]int64),
 		expExhausted:       make(map[int32]bool),
+		friendNames:        make(map[int64]string),
+		visiting:           make(map[int64]bool),
 	}
 }
 
-// getLocalDateKey 获取本地日期键 (YYYY-MM-DD)
-func getLocalDateKey() string {
-	now := time.Now()
-	return fmt.Sprintf("%04d-%02d-%02d", now.Year(), now.Month(), now.Day())
-}
-
-// checkDailyReset 检查是否需要重置每日限制 (0点刷新)
-func (fm *FriendManager) checkDailyReset() {
-	today := getLocalDateKey()
-	if today != fm.lastResetDate {
-		fm.mu.Lock()
-		fm.lastResetDate = today
-		fm.operationLimits = make(map[int32]*plantpb.OperationLimit)
-		fm.expTracker = make(map[int32]int64)
-		fm.expExhausted = make(map[int32]bool)
-		fm.mu.Unlock()
-		utils.Log("好友系统", "每日限制已重置")
-	}
+// resetDailyLimits 游戏日变化时清空经验耗尽标记
+func (fm *FriendManager) resetDailyLimits() {
+	fm.mu.Lock()
+	fm.expTracker = make(map[int32]int64)
+	fm.expExhausted = make(map[int32]bool)
+	fm.mu.Unlock()
+	utils.Log("好友系统", "每日限制已重置")
 }
 
 // GetAllFriends 获取所有好友
@@ -96,16 +88,27 @@ func (fm *FriendManager) GetAllFriends() (*friendpb.GetAllReply, error) {
 	req := &friendpb.GetAllRequest{}
 	resp := &friendpb.GetAllReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.friendpb.FriendService", "GetAll", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.friendpb.FriendService", "GetAll", req, resp, 10*time.Second)
 	return resp, err
 }
 
+// GetFriendName 获取好友昵称 (未知时返回GID)
+func (fm *FriendManager) GetFriendName(gid int64) string {
+	fm.mu.RLock()
+	defer fm.mu.RUnlock()
+	
+	if name, ok := fm.friendNames[gid]; ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("GID:%d", gid)
+}
+
 // GetApplications 获取好友申请列表
 func (fm *FriendManager) GetApplications() (*friendpb.GetApplicationsReply, error) {
 	req := &friendpb.GetApplicationsRequest{}
 	resp := &friendpb.GetApplicationsReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.friendpb.FriendService", "GetApplications", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.friendpb.FriendService", "GetApplications", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -116,7 +119,29 @@ func (fm *FriendManager) AcceptFriends(gids []int64) (*friendpb.AcceptFriendsRep
 	}
 	resp := &friendpb.AcceptFriendsReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.friendpb.FriendService", "AcceptFriends", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.friendpb.FriendService", "AcceptFriends", req, resp, 10*time.Second)
+	return resp, err
+}
+
+// RejectFriends 拒绝好友申请
+func (fm *FriendManager) RejectFriends(gids []int64) (*friendpb.RejectFriendsReply, error) {
+	req := &friendpb.RejectFriendsRequest{
+		FriendGids: gids,
+	}
+	resp := &friendpb.RejectFriendsReply{}
+	
+	err := sendGameRequest("gamepb.friendpb.FriendService", "RejectFriends", req, resp, 10*time.Second)
+	return resp, err
+}
+
+// SetBlockApplications 设置是否屏蔽好友申请
+func (fm *FriendManager) SetBlockApplications(block bool) (*friendpb.SetBlockApplicationsReply, error) {
+	req := &friendpb.SetBlockApplicationsRequest{
+		Block: block,
+	}
+	resp := &friendpb.SetBlockApplicationsReply{}
+	
+	err := sendGameRequest("gamepb.friendpb.FriendService", "SetBlockApplications", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -128,7 +153,7 @@ func (fm *FriendManager) EnterFriendFarm(friendGid int64) (*visitpb.EnterReply,
 	}
 	resp := &visitpb.EnterReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.visitpb.VisitService", "Enter", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.visitpb.VisitService", "Enter", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -140,7 +165,7 @@ func (fm *FriendManager) LeaveFriendFarm(friendGid int64) {
 	resp := &visitpb.LeaveReply{}
 	
 	// 离开失败不影响主流程
-	_ = network.Net.SendProtoMessage("gamepb.visitpb.VisitService", "Leave", req, resp, 5*time.Second)
+	_ = sendGameRequest("gamepb.visitpb.VisitService", "Leave", req, resp, 5*time.Second)
 }
 
 // StealFromFriend 从好友农场偷菜
@@ -152,11 +177,14 @@ func (fm *FriendManager) StealFromFriend(landIds []int64, hostGID int64) (*plant
 	}
 	resp := &plantpb.HarvestReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "Harvest", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "Harvest", req, resp, 10*time.Second)
 	
-	// 更新操作限制
+	// 更新操作限制和农场缓存
 	if err == nil && resp.OperationLimits != nil {
-		fm.updateOperationLimits(resp.OperationLimits)
+		fm.updateOperationLimits(OpSteal, resp.OperationLimits)
+	}
+	if err == nil {
+		VisitCache.Merge(hostGID, resp.Land)
 	}
 	
 	return resp, err
@@ -164,17 +192,38 @@ func (fm *FriendManager) StealFromFriend(landIds []int64, hostGID int64) (*plant
 
 // HelpWaterLand 帮好友浇水
 func (fm *FriendManager) HelpWaterLand(landIds []int64, hostGID int64) (*plantpb.WaterLandReply, error) {
-	return Farm.WaterLand(landIds, hostGID)
+	resp, err := Farm.WaterLand(landIds, hostGID)
+	if err == nil && resp.OperationLimits != nil {
+		fm.updateOperationLimits(OpWaterLand, resp.OperationLimits)
+	}
+	if err == nil {
+		VisitCache.Merge(hostGID, resp.Land)
+	}
+	return resp, err
 }
 
 // HelpWeedOut 帮好友除草
 func (fm *FriendManager) HelpWeedOut(landIds []int64, hostGID int64) (*plantpb.WeedOutReply, error) {
-	return Farm.WeedOut(landIds, hostGID)
+	resp, err := Farm.WeedOut(landIds, hostGID)
+	if err == nil && resp.OperationLimits != nil {
+		fm.updateOperationLimits(OpWeedOut, resp.OperationLimits)
+	}
+	if err == nil {
+		VisitCache.Merge(hostGID, resp.Land)
+	}
+	return resp, err
 }
 
 // HelpInsecticide 帮好友除虫
 func (fm *FriendManager) HelpInsecticide(landIds []int64, hostGID int64) (*plantpb.InsecticideReply, error) {
-	return Farm.Insecticide(landIds, hostGID)
+	resp, err := Farm.Insecticide(landIds, hostGID)
+	if err == nil && resp.OperationLimits != nil {
+		fm.updateOperationLimits(OpInsecticide, resp.OperationLimits)
+	}
+	if err == nil {
+		VisitCache.Merge(hostGID, resp.Land)
+	}
+	return resp, err
 }
 
 // PutWeeds 放草
@@ -185,11 +234,11 @@ func (fm *FriendManager) PutWeeds(landIds []int64, hostGID int64) (*plantpb.PutW
 	}
 	resp := &plantpb.PutWeedsReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "PutWeeds", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "PutWeeds", req, resp, 10*time.Second)
 	
 	// 更新操作限制
 	if err == nil && resp.OperationLimits != nil {
-		fm.updateOperationLimits(resp.OperationLimits)
+		fm.updateOperationLimits(OpPutWeeds, resp.OperationLimits)
 	}
 	
 	return resp, err
@@ -203,38 +252,37 @@ func (fm *FriendManager) PutInsects(landIds []int64, hostGID int64) (*plantpb.Pu
 	}
 	resp := &plantpb.PutInsectsReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.plantpb.PlantService", "PutInsects", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.plantpb.PlantService", "PutInsects", req, resp, 10*time.Second)
 	
 	// 更新操作限制
 	if err == nil && resp.OperationLimits != nil {
-		fm.updateOperationLimits(resp.OperationLimits)
+		fm.updateOperationLimits(OpPutInsects, resp.OperationLimits)
 	}
 	
 	return resp, err
 }
 
-// updateOperationLimits 更新操作限制
-func (fm *FriendManager) updateOperationLimits(limits []*plantpb.OperationLimit) {
+// updateOperationLimits 更新某次操作后的操作限制
+func (fm *FriendManager) updateOperationLimits(opId int32, limits []*plantpb.OperationLimit) {
+	OpLimits.Record(opId, limits)
+	
+	// 检查经验是否耗尽
+	if !HelpOnlyWithExp {
+		return
+	}
+	limit := OpLimits.Limit(opId)
+	if limit == nil {
+		return
+	}
+	
 	fm.mu.Lock()
 	defer fm.mu.Unlock()
 	
-	for _, limit := range limits {
-		if limit != nil {
-			opId := int32(limit.Id)
-			fm.operationLimits[opId] = limit
-			
-			// 检查经验是否耗尽
-			if HelpOnlyWithExp {
-				if beforeExp, ok := fm.expTracker[opId]; ok {
-					if limit.DayExpTimes <= beforeExp {
-						// 经验没有增长，标记为已耗尽
-						if !fm.expExhausted[opId] {
-							fm.expExhausted[opId] = true
-							utils.Log("好友系统", fmt.Sprintf("操作 %s 今日经验已耗尽", OpNames[opId]))
-						}
-					}
-				}
-			}
+	if beforeExp, ok := fm.expTracker[opId]; ok && limit.DayExpTimes <= beforeExp {
+		// 经验没有增长，标记为已耗尽
+		if !fm.expExhausted[opId] {
+			fm.expExhausted[opId] = true
+			utils.Log("好友系统", fmt.Sprintf("操作 %s 今日经验已耗尽", OpNames[opId]))
 		}
 	}
 }
@@ -245,6 +293,11 @@ func (fm *FriendManager) canGetExp(opId int32) bool {
 		return true
 	}
 	
+	// 服务器给出了经验次数上限时以剩余经验次数为准
+	if OpLimits.RemainingExp(opId) == 0 {
+		return false
+	}
+	
 	fm.mu.RLock()
 	defer fm.mu.RUnlock()
 	
@@ -257,51 +310,52 @@ func (fm *FriendManager) trackExpBefore(opId int32) {
 		return
 	}
 	
+	limit := OpLimits.Limit(opId)
+	if limit == nil {
+		return
+	}
+	
 	fm.mu.Lock()
 	defer fm.mu.Unlock()
 	
-	if limit, ok := fm.operationLimits[opId]; ok && limit != nil {
-		fm.expTracker[opId] = limit.DayExpTimes
-	}
+	fm.expTracker[opId] = limit.DayExpTimes
 }
 
 // isLimitReached 检查操作是否达到限制
 func (fm *FriendManager) isLimitReached(opId int32) bool {
-	fm.mu.RLock()
-	defer fm.mu.RUnlock()
-	
-	limit, ok := fm.operationLimits[opId]
-	if !ok || limit == nil {
-		return false
-	}
-	
-	// 检查是否达到每日次数上限
-	if limit.DayTimesLt > 0 && limit.DayTimes >= limit.DayTimesLt {
-		return true
-	}
-	
-	return false
+	return OpLimits.IsReached(opId)
 }
 
-// getRemainingTimes 获取剩余操作次数
+// getRemainingTimes 获取剩余操作次数 (-1 为无限制信息或无上限)
 func (fm *FriendManager) getRemainingTimes(opId int32) int64 {
-	fm.mu.RLock()
-	defer fm.mu.RUnlock()
-	
-	limit, ok := fm.operationLimits[opId]
-	if !ok || limit == nil {
-		return -1 // 无限制信息
+	return OpLimits.Remaining(opId)
+}
+
+// reserveLands 按剩余操作次数截取土地列表并预留次数，请求完成后调用 release
+// 剩余次数已被其他巡查或定时偷菜预留完时返回 ok=false，不应发出请求
+func (fm *FriendManager) reserveLands(opId int32, landIds []int64) ([]int64, func(), bool) {
+	granted := OpLimits.Reserve(opId, int64(len(landIds)))
+	if granted < 0 {
+		return landIds, func() {}, len(landIds) > 0
 	}
-	
-	if limit.DayTimesLt <= 0 {
-		return -1 // 无上限
+	if granted == 0 {
+		return nil, func() {}, false
 	}
-	
-	remaining := limit.DayTimesLt - limit.DayTimes
-	if remaining < 0 {
-		return 0
+	return landIds[:granted], func() { OpLimits.Release(opId, granted) }, true
+}
+
+// batchLandResults 根据回复中的土地列表判断哪些土地操作成功 (回复没有土地时视为全部成功)
+func batchLandResults(landIds []int64, replyLands []*plantpb.LandInfo, done func(land *plantpb.LandInfo) bool) []int64 {
+	if len(replyLands) == 0 {
+		return landIds
+	}
+	succeeded := make([]int64, 0, len(landIds))
+	for _, land := range replyLands {
+		if land != nil && containsInt64(landIds, land.Id) && done(land) {
+			succeeded = append(succeeded, land.Id)
+		}
 	}
-	return remaining
+	return succeeded
 }
 
 // FriendLandStatus 好友农场土地状态
@@ -320,6 +374,7 @@ type StealablePlant struct {
 	LandID    int64
 	PlantID   int64
 	PlantName string
+	FruitID   int64
 	FruitNum  int64
 }
 
@@ -337,7 +392,8 @@ func (fm *FriendManager) AnalyzeFriendLands(lands []*plantpb.LandInfo) *FriendLa
 	
 	nowSec := utils.GetServerTimeSec()
 	
-	for _, land := range lands {
+	// 副地跟随主地，只对主地操作
+	for _, land := range MasterLands(lands) {
 		if land == nil || !land.Unlocked {
 			continue
 		}
@@ -371,6 +427,7 @@ func (fm *FriendManager) AnalyzeFriendLands(lands []*plantpb.LandInfo) *FriendLa
 				LandID:    landID,
 				PlantID:   plant.Id,
 				PlantName: plantName,
+				FruitID:   plant.FruitId,
 				FruitNum:  plant.LeftFruitNum,
 			})
 		}
@@ -421,6 +478,23 @@ func (fm *FriendManager) AnalyzeFriendLands(lands []*plantpb.LandInfo) *FriendLa
 	return result
 }
 
+// hasWork 按规则和今日限制判断农场状态中是否有可做的操作
+func (fm *FriendManager) hasWork(status *FriendLandStatus, rule FriendRule, helpAllowed bool) bool {
+	if len(status.CanSteal) > 0 && rule.AllowSteal() && !fm.isLimitReached(OpSteal) {
+		return true
+	}
+	if !helpAllowed {
+		return false
+	}
+	if len(status.NeedWater) > 0 && fm.canGetExp(OpWaterLand) && !fm.isLimitReached(OpWaterLand) {
+		return true
+	}
+	if len(status.NeedWeed) > 0 && fm.canGetExp(OpWeedOut) && !fm.isLimitReached(OpWeedOut) {
+		return true
+	}
+	return len(status.NeedBug) > 0 && fm.canGetExp(OpInsecticide) && !fm.isLimitReached(OpInsecticide)
+}
+
 // getCurrentPhase 获取当前生长阶段 (从farm.go复用)
 func (fm *FriendManager) getCurrentPhase(phases []*plantpb.PlantPhaseInfo, nowSec int64) *plantpb.PlantPhaseInfo {
 	if len(phases) == 0 {
@@ -439,6 +513,42 @@ func (fm *FriendManager) getCurrentPhase(phases []*plantpb.PlantPhaseInfo, nowSe
 	return phases[0]
 }
 
+// acquireVisit 占用一个拜访名额，该好友的农场正在被拜访时返回false
+func (fm *FriendManager) acquireVisit(gid int64) bool {
+	fm.visitOnce.Do(func() {
+		fm.visitSlots = make(chan struct{}, max(config.Current.FriendPatrolWorkers, 1))
+	})
+	
+	fm.mu.Lock()
+	if fm.visiting[gid] {
+		fm.mu.Unlock()
+		return false
+	}
+	fm.visiting[gid] = true
+	fm.mu.Unlock()
+	
+	fm.visitSlots <- struct{}{}
+	return true
+}
+
+// releaseVisit 释放拜访名额
+func (fm *FriendManager) releaseVisit(gid int64) {
+	<-fm.visitSlots
+	fm.mu.Lock()
+	delete(fm.visiting, gid)
+	fm.mu.Unlock()
+}
+
+// patrolTarget 本轮巡查要拜访的好友
+type patrolTarget struct {
+	friend     *friendpb.GameFriend
+	hints      []string
+	stealValue int64 // 可偷果实的预计价值
+	helpLands  int64 // 可帮忙的土地数
+	thief      bool  // 有可偷作物的负分好友
+	score      int64 // 互惠分
+}
+
 // CheckFriendFarm 检查单个好友农场
 func (fm *FriendManager) CheckFriendFarm(friend *friendpb.GameFriend) {
 	if friend == nil {
@@ -448,6 +558,11 @@ func (fm *FriendManager) CheckFriendFarm(friend *friendpb.GameFriend) {
 	friendGid := friend.Gid
 	friendName := friend.Name
 	
+	if !fm.acquireVisit(friendGid) {
+		return
+	}
+	defer fm.releaseVisit(friendGid)
+	
 	// 进入好友农场
 	utils.Log("好友巡查", fmt.Sprintf("进入 %s 的农场 (GID: %d)", friendName, friendGid))
 	
@@ -465,6 +580,8 @@ func (fm *FriendManager) CheckFriendFarm(friend *friendpb.GameFriend) {
 		return
 	}
 	
+	VisitCache.Store(friendGid, friend.Plant, lands)
+	
 	// 分析土地状态
 	status := fm.AnalyzeFriendLands(lands)
 	
@@ -472,108 +589,121 @@ func (fm *FriendManager) CheckFriendFarm(friend *friendpb.GameFriend) {
 	fm.performFriendOperations(friendGid, friendName, status)
 }
 
-// performFriendOperations 执行好友农场操作
-func (fm *FriendManager) performFriendOperations(friendGid int64, friendName string, status *FriendLandStatus) {
-	// 1. 偷菜 (优先级最高)
-	if len(status.CanSteal) > 0 && !fm.isLimitReached(OpSteal) {
-		stealCount := 0
-		plantNameSet := make(map[string]bool)
-		
+// stealLands 一次请求偷取已预留次数的土地并记录收获
+func (fm *FriendManager) stealLands(friendGid int64, friendName string, status *FriendLandStatus, stealLands []int64) {
+	reply, err := fm.StealFromFriend(stealLands, friendGid)
+	if err != nil {
+		utils.LogWarn("偷菜", fmt.Sprintf("从 %s 的 %d 块地偷菜失败: %v", friendName, len(stealLands), err))
+	} else {
+		before := make(map[int64]StealablePlant, len(status.StealInfo))
 		for _, info := range status.StealInfo {
-			if fm.isLimitReached(OpSteal) {
-				break
-			}
-			
-			_, err := fm.StealFromFriend([]int64{info.LandID}, friendGid)
-			if err != nil {
-				utils.LogWarn("偷菜", fmt.Sprintf("从 %s 的土地#%d 偷菜失败: %v", friendName, info.LandID, err))
-				continue
-			}
-			
-			stealCount++
+			before[info.LandID] = info
+		}
+		stolen := batchLandResults(stealLands, reply.Land, func(land *plantpb.LandInfo) bool {
+			return land.Plant == nil || land.Plant.LeftFruitNum < before[land.Id].FruitNum
+		})
+		
+		harvested := make([]HarvestedLand, 0, len(stolen))
+		plantNameSet := make(map[string]bool)
+		for _, landID := range stolen {
+			info := before[landID]
+			harvested = append(harvested, HarvestedLand{
+				LandID:   info.LandID,
+				PlantID:  info.PlantID,
+				FruitID:  info.FruitID,
+				FruitNum: info.FruitNum,
+			})
 			plantNameSet[info.PlantName] = true
-			
-			// 偷菜间隔
-			time.Sleep(100 * time.Millisecond)
 		}
+		Ledger.RecordHarvest(reply, harvested, HarvestSourceSteal, friendGid, friendName)
 		
-		if stealCount > 0 {
+		if len(stolen) > 0 {
+			Reciprocity.RecordVisit(friendGid, 0, int64(len(stolen)))
+			
 			// 构建植物名称列表（去重）
 			plantNames := make([]string, 0, len(plantNameSet))
 			for name := range plantNameSet {
 				plantNames = append(plantNames, name)
 			}
 			utils.Log("偷菜", fmt.Sprintf("从 %s 偷了 %d 块地的(%s)",
-				friendName, stealCount, strings.Join(plantNames, "/")))
+				friendName, len(stolen), strings.Join(plantNames, "/")))
+		}
+	}
+}
+
+// performFriendOperations 执行好友农场操作
+func (fm *FriendManager) performFriendOperations(friendGid int64, friendName string, status *FriendLandStatus) {
+	rule := FriendRules.RuleFor(friendGid, friendName)
+	
+	// 1. 偷菜 (优先级最高), 所有可偷的土地一次请求
+	if len(status.CanSteal) > 0 && rule.AllowSteal() && !fm.isLimitReached(OpSteal) {
+		if stealLands, release, ok := fm.reserveLands(OpSteal, status.CanSteal); ok {
+			fm.stealLands(friendGid, friendName, status, stealLands)
+			release()
 		}
 	}
 	
+	// 好友规则不允许帮忙或作息为只收获时不帮忙
+	if !rule.AllowHelp() || Schedule.ModeFor(ModuleFriend) == ModeHarvestOnly {
+		return
+	}
+	
+	// 只偷菜/使坏的好友不花有限的帮忙次数
+	if Reciprocity.OnlyTakes(friendGid) {
+		return
+	}
+	
 	// 2. 帮好友浇水
 	if len(status.NeedWater) > 0 && fm.canGetExp(OpWaterLand) && !fm.isLimitReached(OpWaterLand) {
-		fm.trackExpBefore(OpWaterLand)
-		
-		watered := int64(0)
-		for _, landID := range status.NeedWater {
-			if fm.isLimitReached(OpWaterLand) {
-				break
-			}
+		if landIds, release, ok := fm.reserveLands(OpWaterLand, status.NeedWater); ok {
+			fm.trackExpBefore(OpWaterLand)
 			
-			_, err := fm.HelpWaterLand([]int64{landID}, friendGid)
+			reply, err := fm.HelpWaterLand(landIds, friendGid)
+			release()
 			if err != nil {
-				continue
+				utils.LogWarn("帮浇水", fmt.Sprintf("帮 %s 浇水失败: %v", friendName, err))
+			} else if watered := batchLandResults(landIds, reply.Land, func(land *plantpb.LandInfo) bool {
+				return land.Plant == nil || land.Plant.DryNum == 0
+			}); len(watered) > 0 {
+				Reciprocity.RecordVisit(friendGid, int64(len(watered)), 0)
+				utils.Log("帮浇水", fmt.Sprintf("帮 %s 浇了 %d 块地", friendName, len(watered)))
 			}
-			watered++
-			time.Sleep(50 * time.Millisecond)
-		}
-		
-		if watered > 0 {
-			utils.Log("帮浇水", fmt.Sprintf("帮 %s 浇了 %d 块地", friendName, watered))
 		}
 	}
 	
 	// 3. 帮好友除草
 	if len(status.NeedWeed) > 0 && fm.canGetExp(OpWeedOut) && !fm.isLimitReached(OpWeedOut) {
-		fm.trackExpBefore(OpWeedOut)
-		
-		weeded := int64(0)
-		for _, landID := range status.NeedWeed {
-			if fm.isLimitReached(OpWeedOut) {
-				break
-			}
+		if landIds, release, ok := fm.reserveLands(OpWeedOut, status.NeedWeed); ok {
+			fm.trackExpBefore(OpWeedOut)
 			
-			_, err := fm.HelpWeedOut([]int64{landID}, friendGid)
+			reply, err := fm.HelpWeedOut(landIds, friendGid)
+			release()
 			if err != nil {
-				continue
+				utils.LogWarn("帮除草", fmt.Sprintf("帮 %s 除草失败: %v", friendName, err))
+			} else if weeded := batchLandResults(landIds, reply.Land, func(land *plantpb.LandInfo) bool {
+				return land.Plant == nil || len(land.Plant.WeedOwners) == 0
+			}); len(weeded) > 0 {
+				Reciprocity.RecordVisit(friendGid, int64(len(weeded)), 0)
+				utils.Log("帮除草", fmt.Sprintf("帮 %s 除了 %d 块地的草", friendName, len(weeded)))
 			}
-			weeded++
-			time.Sleep(50 * time.Millisecond)
-		}
-		
-		if weeded > 0 {
-			utils.Log("帮除草", fmt.Sprintf("帮 %s 除了 %d 块地的草", friendName, weeded))
 		}
 	}
 	
 	// 4. 帮好友除虫
 	if len(status.NeedBug) > 0 && fm.canGetExp(OpInsecticide) && !fm.isLimitReached(OpInsecticide) {
-		fm.trackExpBefore(OpInsecticide)
-		
-		bugged := int64(0)
-		for _, landID := range status.NeedBug {
-			if fm.isLimitReached(OpInsecticide) {
-				break
-			}
+		if landIds, release, ok := fm.reserveLands(OpInsecticide, status.NeedBug); ok {
+			fm.trackExpBefore(OpInsecticide)
 			
-			_, err := fm.HelpInsecticide([]int64{landID}, friendGid)
+			reply, err := fm.HelpInsecticide(landIds, friendGid)
+			release()
 			if err != nil {
-				continue
+				utils.LogWarn("帮除虫", fmt.Sprintf("帮 %s 除虫失败: %v", friendName, err))
+			} else if bugged := batchLandResults(landIds, reply.Land, func(land *plantpb.LandInfo) bool {
+				return land.Plant == nil || len(land.Plant.InsectOwners) == 0
+			}); len(bugged) > 0 {
+				Reciprocity.RecordVisit(friendGid, int64(len(bugged)), 0)
+				utils.Log("帮除虫", fmt.Sprintf("帮 %s 除了 %d 块地的虫", friendName, len(bugged)))
 			}
-			bugged++
-			time.Sleep(50 * time.Millisecond)
-		}
-		
-		if bugged > 0 {
-			utils.Log("帮除虫", fmt.Sprintf("帮 %s 除了 %d 块地的虫", friendName, bugged))
 		}
 	}
 	
@@ -609,8 +739,18 @@ func (fm *FriendManager) CheckAllFriends() {
 	fm.isCheckingFriends = true
 	defer func() { fm.isCheckingFriends = false }()
 	
-	// 检查每日重置
-	fm.checkDailyReset()
+	// 作息: 暂停时不拜访好友，只收获时只偷菜
+	mode := Schedule.ModeFor(ModuleFriend)
+	if mode == ModePaused {
+		return
+	}
+	stealOnly := mode == ModeHarvestOnly
+	
+	// 检查每日重置 (按服务器时间)
+	DayReset.Check()
+	
+	// 规则文件修改或删除后在本次巡查生效
+	FriendRules.Refresh()
 	
 	// 获取好友列表
 	friendsReply, err := fm.GetAllFriends()
@@ -625,10 +765,29 @@ func (fm *FriendManager) CheckAllFriends() {
 		return
 	}
 	
+	fm.mu.Lock()
+	for _, friend := range friends {
+		if friend != nil {
+			fm.friendNames[friend.Gid] = friend.Name
+		}
+	}
+	fm.mu.Unlock()
+	
+	// 更新好友档案
+	Directory.Update(friends)
+	
+	// 按成熟倒计时安排定时偷菜
+	Stealer.Update(friends)
+	
 	utils.Log("好友系统", fmt.Sprintf("开始巡查 %d 位好友的农场", len(friends)))
+	if summary := OpLimits.Summary(); summary != "" {
+		utils.Log("好友系统", "今日剩余: "+summary)
+	}
 	
-	// 遍历好友
-	for i, friend := range friends {
+	// 筛选有可做操作的好友
+	unchanged := 0
+	var targets []*patrolTarget
+	for _, friend := range friends {
 		if friend == nil {
 			continue
 		}
@@ -639,43 +798,98 @@ func (fm *FriendManager) CheckAllFriends() {
 			continue
 		}
 		
+		// 好友规则: 跳过的好友、不活跃的好友不拜访
+		if !FriendRules.ShouldVisit(friend) {
+			continue
+		}
+		rule := FriendRules.RuleFor(friend.Gid, friend.Name)
+		helpAllowed := !stealOnly && rule.AllowHelp() && !Reciprocity.OnlyTakes(friend.Gid)
+		
 		// 快速筛选：有可偷作物、需要帮助的好友
-		hasAction := false
-		actionHints := []string{}
+		target := &patrolTarget{friend: friend}
 		
-		if plant.StealPlantNum > 0 && !fm.isLimitReached(OpSteal) {
-			hasAction = true
-			actionHints = append(actionHints, fmt.Sprintf("可偷%d个", plant.StealPlantNum))
+		if plant.StealPlantNum > 0 && rule.AllowSteal() && !fm.isLimitReached(OpSteal) {
+			target.stealValue = plant.StealPlantNum * fruitValue(plant.RipeFruitId)
+			target.hints = append(target.hints, fmt.Sprintf("可偷%d个", plant.StealPlantNum))
 		}
 		
-		if plant.DryNum > 0 && fm.canGetExp(OpWaterLand) && !fm.isLimitReached(OpWaterLand) {
-			hasAction = true
-			actionHints = append(actionHints, fmt.Sprintf("需浇水%d块", plant.DryNum))
+		if plant.DryNum > 0 && helpAllowed && fm.canGetExp(OpWaterLand) && !fm.isLimitReached(OpWaterLand) {
+			target.helpLands += plant.DryNum
+			target.hints = append(target.hints, fmt.Sprintf("需浇水%d块", plant.DryNum))
 		}
 		
-		if plant.WeedNum > 0 && fm.canGetExp(OpWeedOut) && !fm.isLimitReached(OpWeedOut) {
-			hasAction = true
-			actionHints = append(actionHints, fmt.Sprintf("需除草%d块", plant.WeedNum))
+		if plant.WeedNum > 0 && helpAllowed && fm.canGetExp(OpWeedOut) && !fm.isLimitReached(OpWeedOut) {
+			target.helpLands += plant.WeedNum
+			target.hints = append(target.hints, fmt.Sprintf("需除草%d块", plant.WeedNum))
 		}
 		
-		if plant.InsectNum > 0 && fm.canGetExp(OpInsecticide) && !fm.isLimitReached(OpInsecticide) {
-			hasAction = true
-			actionHints = append(actionHints, fmt.Sprintf("需除虫%d块", plant.InsectNum))
+		if plant.InsectNum > 0 && helpAllowed && fm.canGetExp(OpInsecticide) && !fm.isLimitReached(OpInsecticide) {
+			target.helpLands += plant.InsectNum
+			target.hints = append(target.hints, fmt.Sprintf("需除虫%d块", plant.InsectNum))
 		}
 		
-		if !hasAction {
+		if len(target.hints) == 0 {
 			continue
 		}
 		
-		utils.Log("好友巡查", fmt.Sprintf("[%d/%d] %s: %s", i+1, len(friends), friend.Name, actionHints))
-		
-		// 检查该好友农场
-		fm.CheckFriendFarm(friend)
+		// 摘要与上次拜访时相同且按缓存推算没有可做的操作: 不进入农场
+		if VisitCache.Unchanged(friend) {
+			if predicted := VisitCache.Predict(friend.Gid); predicted != nil && !fm.hasWork(predicted, rule, helpAllowed) {
+				unchanged++
+				continue
+			}
+		}
 		
-		// 好友间巡查间隔
-		time.Sleep(config.Current.FriendCheckInterval)
+		targets = append(targets, target)
+	}
+	
+	// 巡查顺序: 先偷小偷 (互惠分从低到高), 其余按预计收益 (偷菜价值, 其次可帮忙的土地数), 收益相同时先帮互惠分高的好友
+	for _, target := range targets {
+		target.thief, target.score = Reciprocity.VisitPriority(target.friend)
 	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		a, b := targets[i], targets[j]
+		if a.thief != b.thief {
+			return a.thief
+		}
+		if a.thief && a.score != b.score {
+			return a.score < b.score
+		}
+		if a.stealValue != b.stealValue {
+			return a.stealValue > b.stealValue
+		}
+		if a.helpLands != b.helpLands {
+			return a.helpLands > b.helpLands
+		}
+		return a.score > b.score
+	})
 	
+	// 多个农场同时拜访, 每个农场内按 进入→操作→离开 顺序 (请求节奏由全局限速控制, 农场之间不再额外等待)
+	workers := min(max(config.Current.FriendPatrolWorkers, 1), max(len(targets), 1))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				target := targets[i]
+				utils.Log("好友巡查", fmt.Sprintf("[%d/%d] %s: %s", i+1, len(targets), target.friend.Name, target.hints))
+				
+				// 检查该好友农场
+				fm.CheckFriendFarm(target.friend)
+			}
+		}()
+	}
+	for i := range targets {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	
+	if unchanged > 0 {
+		utils.Log("好友系统", fmt.Sprintf("%d 位好友的农场与上次拜访时相同, 未进入", unchanged))
+	}
 	utils.Log("好友系统", "好友农场巡查完成")
 	fm.isFirstFriendCheck = false
 }
@@ -729,7 +943,7 @@ func (fm *FriendManager) StartFriendCheckLoop() {
 	go func() {
 		for fm.friendLoopRunning {
 			// 等待间隔时间
-			time.Sleep(config.Current.FriendCheckInterval)
+			time.Sleep(Schedule.Interval(ModuleFriend, config.Current.FriendCheckInterval))
 			
 			if !fm.friendLoopRunning {
 				break
@@ -754,6 +968,7 @@ func (fm *FriendManager) StopFriendCheckLoop() {
 	if fm.friendCheckTimer != nil {
 		fm.friendCheckTimer.Stop()
 	}
+	Stealer.Stop()
 	utils.Log("好友系统", "好友巡查循环已停止")
 }
 
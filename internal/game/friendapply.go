@@ -0,0 +1,216 @@
+package game
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"gofarm/internal/config"
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/friendpb"
+)
+
+// ApplicationHandler 好友申请处理: 实时响应申请推送，按规则同意/拒绝，好友数满时屏蔽申请
+type ApplicationHandler struct {
+	networkEvents *network.EventEmitter
+	blocked       bool // 当前是否屏蔽申请
+	running       bool
+	mu            sync.Mutex
+	handleMu      sync.Mutex // 串行处理申请，避免推送和启动时的积压同时处理导致超出好友上限
+}
+
+var Applications *ApplicationHandler
+
+func init() {
+	Applications = &ApplicationHandler{
+		networkEvents: network.Net.GetEvents(),
+	}
+}
+
+// evaluateApplication 按规则判断是否同意申请，返回是否同意和原因
+func evaluateApplication(app *friendpb.Application, friendCount int) (bool, string) {
+	cfg := config.Current
+	if cfg.ApplyMaxFriends > 0 && friendCount >= cfg.ApplyMaxFriends {
+		return false, fmt.Sprintf("好友数已达上限 %d", cfg.ApplyMaxFriends)
+	}
+	if cfg.ApplyMinLevel > 0 && app.Level < int64(cfg.ApplyMinLevel) {
+		return false, fmt.Sprintf("等级 %d 低于 %d", app.Level, cfg.ApplyMinLevel)
+	}
+	if cfg.ApplyWxOnly && app.OpenId == "" {
+		return false, "不是微信好友"
+	}
+	if cfg.ApplyNamePattern != "" {
+		re, err := regexp.Compile(cfg.ApplyNamePattern)
+		if err != nil {
+			return false, fmt.Sprintf("昵称规则无效: %v", err)
+		}
+		if !re.MatchString(app.Name) {
+			return false, "昵称不匹配"
+		}
+	}
+	return true, ""
+}
+
+// friendCount 当前好友数
+func (ah *ApplicationHandler) friendCount() (int, error) {
+	reply, err := Friend.GetAllFriends()
+	if err != nil {
+		return 0, err
+	}
+	return len(reply.GameFriends), nil
+}
+
+// HandleApplications 按规则处理一批好友申请
+func (ah *ApplicationHandler) HandleApplications(apps []*friendpb.Application) {
+	if len(apps) == 0 {
+		return
+	}
+	ah.handleMu.Lock()
+	defer ah.handleMu.Unlock()
+
+	// 好友数未知时不同意申请也不调整屏蔽，以免超出上限
+	count, countErr := ah.friendCount()
+	if countErr != nil {
+		utils.LogWarn("好友申请", fmt.Sprintf("获取好友数失败, 本次不同意申请: %v", countErr))
+	}
+	var accepted, rejected []int64
+	for _, app := range apps {
+		if app == nil {
+			continue
+		}
+		ok, reason := evaluateApplication(app, count+len(accepted))
+		if ok && countErr != nil {
+			utils.Log("好友申请", fmt.Sprintf("暂不处理 %s (GID:%d, Lv%d): 好友数未知", app.Name, app.Gid, app.Level))
+			continue
+		}
+		if ok {
+			accepted = append(accepted, app.Gid)
+			utils.Log("好友申请", fmt.Sprintf("同意 %s (GID:%d, Lv%d)", app.Name, app.Gid, app.Level))
+			continue
+		}
+		if config.Current.ApplyRejectUnmatched {
+			rejected = append(rejected, app.Gid)
+			utils.Log("好友申请", fmt.Sprintf("拒绝 %s (GID:%d, Lv%d): %s", app.Name, app.Gid, app.Level, reason))
+		} else {
+			utils.Log("好友申请", fmt.Sprintf("暂不处理 %s (GID:%d, Lv%d): %s", app.Name, app.Gid, app.Level, reason))
+		}
+	}
+
+	if len(accepted) > 0 {
+		if _, err := Friend.AcceptFriends(accepted); err != nil {
+			utils.LogWarn("好友申请", fmt.Sprintf("同意好友申请失败: %v", err))
+		} else {
+			count += len(accepted)
+		}
+	}
+	if len(rejected) > 0 {
+		if _, err := Friend.RejectFriends(rejected); err != nil {
+			utils.LogWarn("好友申请", fmt.Sprintf("拒绝好友申请失败: %v", err))
+		}
+	}
+
+	if countErr == nil {
+		ah.updateBlock(count)
+	}
+}
+
+// updateBlock 好友数达到上限时屏蔽申请，低于上限时解除屏蔽
+func (ah *ApplicationHandler) updateBlock(friendCount int) {
+	if !config.Current.ApplyBlockWhenFull || config.Current.ApplyMaxFriends <= 0 {
+		return
+	}
+	block := friendCount >= config.Current.ApplyMaxFriends
+
+	ah.mu.Lock()
+	unchanged := ah.blocked == block
+	ah.mu.Unlock()
+	if unchanged {
+		return
+	}
+
+	reply, err := Friend.SetBlockApplications(block)
+	if err != nil {
+		utils.LogWarn("好友申请", fmt.Sprintf("设置屏蔽申请失败: %v", err))
+		return
+	}
+	if !IsDryRun() {
+		block = reply.Block
+	}
+	ah.mu.Lock()
+	ah.blocked = block
+	ah.mu.Unlock()
+	if block {
+		utils.Log("好友申请", fmt.Sprintf("好友数 %d 已达上限, 已屏蔽好友申请", friendCount))
+	} else {
+		utils.Log("好友申请", fmt.Sprintf("好友数 %d 低于上限, 已解除屏蔽", friendCount))
+	}
+}
+
+// handleApplicationNotify 处理收到好友申请推送
+func (ah *ApplicationHandler) handleApplicationNotify(data interface{}) {
+	body, ok := data.([]byte)
+	if !ok {
+		return
+	}
+	var notify friendpb.FriendApplicationReceivedNotify
+	if err := proto.Unmarshal(body, &notify); err != nil {
+		return
+	}
+	ah.HandleApplications(notify.Applications)
+}
+
+// handleFriendAddedNotify 处理好友添加成功推送
+func (ah *ApplicationHandler) handleFriendAddedNotify(data interface{}) {
+	body, ok := data.([]byte)
+	if !ok {
+		return
+	}
+	var notify friendpb.FriendAddedNotify
+	if err := proto.Unmarshal(body, &notify); err != nil {
+		return
+	}
+	for _, friend := range notify.Friends {
+		if friend == nil {
+			continue
+		}
+		Friend.mu.Lock()
+		Friend.friendNames[friend.Gid] = friend.Name
+		Friend.mu.Unlock()
+		utils.Log("好友申请", fmt.Sprintf("新好友: %s (GID:%d, Lv%d)", friend.Name, friend.Gid, friend.Level))
+	}
+}
+
+// StartApplicationHandler 处理积压的申请并开始监听申请推送
+func (ah *ApplicationHandler) StartApplicationHandler() {
+	ah.mu.Lock()
+	if ah.running {
+		ah.mu.Unlock()
+		return
+	}
+	ah.running = true
+	ah.mu.Unlock()
+
+	ah.networkEvents.On("friendApplicationReceived", ah.handleApplicationNotify)
+	ah.networkEvents.On("friendAdded", ah.handleFriendAddedNotify)
+
+	reply, err := Friend.GetApplications()
+	if err != nil {
+		utils.LogWarn("好友申请", fmt.Sprintf("获取好友申请失败: %v", err))
+		return
+	}
+	ah.mu.Lock()
+	ah.blocked = reply.BlockApplications
+	ah.mu.Unlock()
+
+	if len(reply.Applications) > 0 {
+		utils.Log("好友申请", fmt.Sprintf("有 %d 个待处理的好友申请", len(reply.Applications)))
+		ah.HandleApplications(reply.Applications)
+	} else if count, err := ah.friendCount(); err != nil {
+		utils.LogWarn("好友申请", fmt.Sprintf("获取好友数失败: %v", err))
+	} else {
+		ah.updateBlock(count)
+	}
+}
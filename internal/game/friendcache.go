@@ -0,0 +1,125 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/friendpb"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// friendVisit 上次拜访时好友农场的土地和好友列表摘要
+type friendVisit struct {
+	lands     []*plantpb.LandInfo
+	summary   *friendpb.Plant
+	ripeAt    int64 // 摘要中的成熟时间 (服务器时间, 秒)
+	visitedAt int64
+}
+
+// FriendVisitCache 好友农场缓存: 好友列表摘要与上次拜访时相同且按土地计时推算没有可做的操作时不再进入农场
+type FriendVisitCache struct {
+	visits map[int64]*friendVisit
+	mu     sync.Mutex
+}
+
+var VisitCache *FriendVisitCache
+
+func init() {
+	VisitCache = &FriendVisitCache{
+		visits: make(map[int64]*friendVisit),
+	}
+}
+
+// Store 记录一次拜访看到的土地 (summary 为好友列表中的摘要，定时偷菜拜访时为入队时的摘要)
+func (vc *FriendVisitCache) Store(gid int64, summary *friendpb.Plant, lands []*plantpb.LandInfo) {
+	now := utils.GetServerTimeSec()
+	visit := &friendVisit{
+		lands:     lands,
+		summary:   summary,
+		visitedAt: now,
+	}
+	if summary != nil && summary.RipeTimeSec > 0 {
+		visit.ripeAt = now + summary.RipeTimeSec
+	}
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+	vc.visits[gid] = visit
+}
+
+// Merge 用操作回复中的土地更新缓存
+func (vc *FriendVisitCache) Merge(gid int64, lands []*plantpb.LandInfo) {
+	if len(lands) == 0 {
+		return
+	}
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	visit, ok := vc.visits[gid]
+	if !ok {
+		return
+	}
+	updated := make(map[int64]*plantpb.LandInfo, len(lands))
+	for _, land := range lands {
+		if land != nil {
+			updated[land.Id] = land
+		}
+	}
+	merged := make([]*plantpb.LandInfo, 0, len(visit.lands))
+	for _, land := range visit.lands {
+		if land != nil {
+			if newer, ok := updated[land.Id]; ok {
+				land = newer
+			}
+		}
+		merged = append(merged, land)
+	}
+	visit.lands = merged
+}
+
+// Unchanged 好友列表摘要是否与上次拜访时相同 (缓存过期视为有变化)
+func (vc *FriendVisitCache) Unchanged(friend *friendpb.GameFriend) bool {
+	ttl := config.Current.FriendVisitCacheTTL
+	plant := friend.GetPlant()
+	if ttl <= 0 || plant == nil {
+		return false
+	}
+	now := utils.GetServerTimeSec()
+
+	vc.mu.Lock()
+	defer vc.mu.Unlock()
+
+	visit, ok := vc.visits[friend.GetGid()]
+	if !ok || visit.summary == nil || time.Duration(now-visit.visitedAt)*time.Second > ttl {
+		return false
+	}
+	prev := visit.summary
+	if plant.DryNum != prev.DryNum || plant.WeedNum != prev.WeedNum || plant.InsectNum != prev.InsectNum ||
+		plant.StealPlantNum != prev.StealPlantNum || plant.RipeFruitId != prev.RipeFruitId {
+		return false
+	}
+	// 成熟倒计时换算为成熟时间比较 (允许几秒误差)
+	ripeAt := int64(0)
+	if plant.RipeTimeSec > 0 {
+		ripeAt = now + plant.RipeTimeSec
+	}
+	diff := ripeAt - visit.ripeAt
+	return diff >= -5 && diff <= 5
+}
+
+// Predict 按缓存土地的计时推算当前的农场状态 (没有缓存时返回nil)
+func (vc *FriendVisitCache) Predict(gid int64) *FriendLandStatus {
+	vc.mu.Lock()
+	visit, ok := vc.visits[gid]
+	var lands []*plantpb.LandInfo
+	if ok {
+		lands = visit.lands
+	}
+	vc.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return Friend.AnalyzeFriendLands(lands)
+}
@@ -0,0 +1,276 @@
+package game
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/friendpb"
+)
+
+// 每位好友最多保留的等级/金币变化记录
+const friendHistoryLimit = 200
+
+// FriendSample 好友等级和金币的一次记录
+type FriendSample struct {
+	Time  int64 `json:"time"`
+	Level int64 `json:"level"`
+	Gold  int64 `json:"gold"`
+}
+
+// FriendProfile 好友档案
+type FriendProfile struct {
+	GID              int64          `json:"gid"`
+	OpenID           string         `json:"openId,omitempty"`
+	Name             string         `json:"name"`
+	Remark           string         `json:"remark,omitempty"`
+	Level            int64          `json:"level"`
+	Gold             int64          `json:"gold"`
+	IsNew            bool           `json:"isNew"`
+	IsFollow         bool           `json:"isFollow"`
+	AuthorizedStatus int32          `json:"authorizedStatus"`
+	FirstSeen        int64          `json:"firstSeen"`
+	LastSeen         int64          `json:"lastSeen"`
+	LastActive       int64          `json:"lastActive"` // 最近一次等级或作物变化的时间
+	RipeFruitID      int64          `json:"ripeFruitId,omitempty"`
+	RipeAt           int64          `json:"ripeAt,omitempty"` // 好友列表中的成熟时间 (用于判断作物变化)
+	History          []FriendSample `json:"history,omitempty"`
+}
+
+// Inactive 好友是否已超过 days 天没有等级或作物变化
+func (fp *FriendProfile) Inactive(days int, now int64) bool {
+	if days <= 0 {
+		return false
+	}
+	return now-fp.LastActive > int64(days)*24*3600
+}
+
+// FriendDirectory 好友档案: 按账号保存每位好友的资料、首次/最近出现时间和等级金币变化
+type FriendDirectory struct {
+	gid      int64
+	profiles map[int64]*FriendProfile
+	mu       sync.Mutex
+}
+
+var Directory *FriendDirectory
+
+func init() {
+	Directory = &FriendDirectory{
+		profiles: make(map[int64]*FriendProfile),
+	}
+}
+
+// friendDirectoryPath 账号的好友档案文件路径
+func friendDirectoryPath(gid int64) string {
+	return filepath.Join(StateDir, fmt.Sprintf("friends-%d.json", gid))
+}
+
+// readFriendDirectory 读取好友档案文件
+func readFriendDirectory(path string) ([]*FriendProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var profiles []*FriendProfile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf("解析 %s 失败: %v", path, err)
+	}
+	return profiles, nil
+}
+
+// Load 登录后加载该账号的好友档案
+func (fd *FriendDirectory) Load(gid int64) {
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+
+	fd.gid = gid
+	fd.profiles = make(map[int64]*FriendProfile)
+	profiles, err := readFriendDirectory(friendDirectoryPath(gid))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogWarn("好友档案", err.Error())
+		}
+		return
+	}
+	now := utils.GetServerTimeSec()
+	inactive := 0
+	for _, profile := range profiles {
+		fd.profiles[profile.GID] = profile
+		if profile.Inactive(config.Current.FriendInactiveDays, now) {
+			inactive++
+		}
+	}
+	utils.Log("好友档案", fmt.Sprintf("已加载 %d 位好友的档案, %d 位超过 %d 天不活跃", len(profiles), inactive, config.Current.FriendInactiveDays))
+}
+
+// Update 用好友列表更新档案
+func (fd *FriendDirectory) Update(friends []*friendpb.GameFriend) {
+	now := utils.GetServerTimeSec()
+
+	fd.mu.Lock()
+	defer fd.mu.Unlock()
+	if fd.gid == 0 {
+		return
+	}
+
+	for _, friend := range friends {
+		if friend == nil {
+			continue
+		}
+		profile, ok := fd.profiles[friend.Gid]
+		if !ok {
+			profile = &FriendProfile{GID: friend.Gid, FirstSeen: now, LastActive: now}
+			fd.profiles[friend.Gid] = profile
+		}
+
+		// 等级或金币变化时记录
+		if !ok || friend.Level != profile.Level || friend.Gold != profile.Gold {
+			profile.History = append(profile.History, FriendSample{Time: now, Level: friend.Level, Gold: friend.Gold})
+			if len(profile.History) > friendHistoryLimit {
+				profile.History = profile.History[len(profile.History)-friendHistoryLimit:]
+			}
+		}
+		if ok && friend.Level != profile.Level {
+			profile.LastActive = now
+		}
+
+		// 成熟时间或果实变化说明好友重新种植了 (作物成熟后倒计时归零不算)
+		if plant := friend.Plant; plant != nil {
+			ripeAt := int64(0)
+			if plant.RipeTimeSec > 0 {
+				ripeAt = now + plant.RipeTimeSec
+			}
+			diff := ripeAt - profile.RipeAt
+			if ok && ripeAt > 0 && (plant.RipeFruitId != profile.RipeFruitID || diff < -60 || diff > 60) {
+				profile.LastActive = now
+			}
+			profile.RipeFruitID = plant.RipeFruitId
+			profile.RipeAt = ripeAt
+		}
+
+		profile.OpenID = friend.OpenId
+		profile.Name = friend.Name
+		profile.Remark = friend.Remark
+		profile.Level = friend.Level
+		profile.Gold = friend.Gold
+		profile.IsNew = friend.GetTags().GetIsNew()
+		profile.IsFollow = friend.GetTags().GetIsFollow()
+		profile.AuthorizedStatus = friend.AuthorizedStatus
+		profile.LastSeen = now
+	}
+
+	fd.save()
+}
+
+// save 保存档案 (调用方持有锁)
+func (fd *FriendDirectory) save() {
+	profiles := make([]*FriendProfile, 0, len(fd.profiles))
+	for _, profile := range fd.profiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].GID < profiles[j].GID })
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(StateDir, 0755); err != nil {
+		return
+	}
+	if err := os.WriteFile(friendDirectoryPath(fd.gid), data, 0644); err != nil {
+		utils.LogWarn("好友档案", fmt.Sprintf("保存好友档案失败: %v", err))
+	}
+}
+
+// LoadFriendDirectory 读取账号的好友档案 (gid 为0时使用唯一的档案文件)，按最近活跃时间降序
+func LoadFriendDirectory(gid int64) ([]*FriendProfile, error) {
+	path := friendDirectoryPath(gid)
+	if gid == 0 {
+		matches, _ := filepath.Glob(filepath.Join(StateDir, "friends-*.json"))
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("%s 中没有好友档案", StateDir)
+		case 1:
+			path = matches[0]
+		default:
+			return nil, fmt.Errorf("有多个账号的好友档案, 请用 --gid 指定: %v", matches)
+		}
+	}
+
+	profiles, err := readFriendDirectory(path)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(profiles, func(i, j int) bool { return profiles[i].LastActive > profiles[j].LastActive })
+	return profiles, nil
+}
+
+// formatDirTime 档案时间文本
+func formatDirTime(sec int64) string {
+	if sec <= 0 {
+		return "-"
+	}
+	return time.Unix(sec, 0).In(GameLocation).Format("2006-01-02 15:04")
+}
+
+// PrintFriendDirectory 输出好友档案列表
+func PrintFriendDirectory(profiles []*FriendProfile, inactiveDays int) {
+	now := time.Now().Unix()
+	inactive := 0
+	for _, p := range profiles {
+		flag := ""
+		if p.Inactive(inactiveDays, now) {
+			flag = " [不活跃]"
+			inactive++
+		}
+		name := p.Name
+		if p.Remark != "" {
+			name += "(" + p.Remark + ")"
+		}
+		levelChange := ""
+		if len(p.History) > 1 {
+			if delta := p.Level - p.History[0].Level; delta > 0 {
+				levelChange = fmt.Sprintf(" +%d级", delta)
+			}
+		}
+		fmt.Printf("%-12d %s Lv%d%s 金币%d 首次 %s 最近 %s 活跃 %s%s\n", p.GID, name, p.Level, levelChange, p.Gold,
+			formatDirTime(p.FirstSeen), formatDirTime(p.LastSeen), formatDirTime(p.LastActive), flag)
+	}
+	fmt.Printf("共 %d 位好友, %d 位超过 %d 天没有等级或作物变化\n", len(profiles), inactive, inactiveDays)
+}
+
+// ExportFriendDirectoryJSON 导出好友档案为JSON
+func ExportFriendDirectoryJSON(w io.Writer, profiles []*FriendProfile) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(profiles)
+}
+
+// ExportFriendDirectoryCSV 导出好友档案为CSV (不含历史记录)
+func ExportFriendDirectoryCSV(w io.Writer, profiles []*FriendProfile, inactiveDays int) error {
+	now := time.Now().Unix()
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"gid", "open_id", "name", "remark", "level", "gold", "is_new", "is_follow",
+		"authorized_status", "first_seen", "last_seen", "last_active", "inactive"})
+	for _, p := range profiles {
+		cw.Write([]string{
+			strconv.FormatInt(p.GID, 10), p.OpenID, p.Name, p.Remark,
+			strconv.FormatInt(p.Level, 10), strconv.FormatInt(p.Gold, 10),
+			strconv.FormatBool(p.IsNew), strconv.FormatBool(p.IsFollow),
+			strconv.Itoa(int(p.AuthorizedStatus)),
+			formatDirTime(p.FirstSeen), formatDirTime(p.LastSeen), formatDirTime(p.LastActive),
+			strconv.FormatBool(p.Inactive(inactiveDays, now)),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
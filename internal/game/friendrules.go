@@ -0,0 +1,182 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/friendpb"
+)
+
+// 好友行为模式
+const (
+	FriendModeFull      = "full"       // 偷菜 + 帮忙
+	FriendModeHelpOnly  = "help-only"  // 只帮忙，不偷菜
+	FriendModeStealOnly = "steal-only" // 只偷菜，不帮忙
+	FriendModeSkip      = "skip"       // 不拜访
+)
+
+// FriendRule 好友行为规则
+type FriendRule struct {
+	Friends      []string `json:"friends,omitempty"`      // 好友GID或昵称 (默认规则不需要)
+	Mode         string   `json:"mode"`                   // full/help-only/steal-only/skip
+	SkipInactive bool     `json:"skipInactive,omitempty"` // 农场没有作物在长时不拜访
+	Note         string   `json:"note,omitempty"`
+}
+
+// friendRulesFile 规则文件格式
+type friendRulesFile struct {
+	Default FriendRule   `json:"default"`
+	Rules   []FriendRule `json:"rules"`
+}
+
+// FriendRuleBook 好友规则: 从规则文件加载，每次巡查前检查文件是否修改或删除
+type FriendRuleBook struct {
+	data    friendRulesFile
+	modTime time.Time
+	mu      sync.RWMutex
+}
+
+var FriendRules *FriendRuleBook
+
+func init() {
+	FriendRules = &FriendRuleBook{
+		data: friendRulesFile{Default: FriendRule{Mode: FriendModeFull}},
+	}
+}
+
+// ValidateFriendMode 检查好友行为模式
+func ValidateFriendMode(mode string) error {
+	switch mode {
+	case FriendModeFull, FriendModeHelpOnly, FriendModeStealOnly, FriendModeSkip:
+		return nil
+	}
+	return fmt.Errorf("未知的好友行为模式: %s", mode)
+}
+
+// AllowSteal 是否允许偷菜
+func (r FriendRule) AllowSteal() bool {
+	return r.Mode == FriendModeFull || r.Mode == FriendModeStealOnly
+}
+
+// AllowHelp 是否允许帮忙
+func (r FriendRule) AllowHelp() bool {
+	return r.Mode == FriendModeFull || r.Mode == FriendModeHelpOnly
+}
+
+// matches 规则是否适用于该好友
+func (r FriendRule) matches(gid int64, name string) bool {
+	gidStr := strconv.FormatInt(gid, 10)
+	for _, friend := range r.Friends {
+		friend = strings.TrimSpace(friend)
+		if friend == gidStr || (name != "" && friend == name) {
+			return true
+		}
+	}
+	return false
+}
+
+// markChecked 记下已检查过的文件修改时间，无效的文件修正前不再重复报错 (保留之前的规则)
+func (rb *FriendRuleBook) markChecked(modTime time.Time) {
+	rb.mu.Lock()
+	rb.modTime = modTime
+	rb.mu.Unlock()
+}
+
+// Refresh 规则文件有变化时重新加载, 文件被删除时恢复默认规则 (运行时修改无需重启, 每次巡查前调用一次)
+func (rb *FriendRuleBook) Refresh() {
+	path := config.Current.FriendRulesFile
+	if path == "" {
+		return
+	}
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		rb.mu.Lock()
+		loaded := !rb.modTime.IsZero()
+		rb.data = friendRulesFile{Default: FriendRule{Mode: FriendModeFull}}
+		rb.modTime = time.Time{}
+		rb.mu.Unlock()
+		if loaded {
+			utils.Log("好友规则", fmt.Sprintf("%s 已删除, 恢复默认规则", path))
+		}
+		return
+	}
+	if err != nil {
+		return
+	}
+
+	rb.mu.RLock()
+	unchanged := info.ModTime().Equal(rb.modTime)
+	rb.mu.RUnlock()
+	if unchanged {
+		return
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var parsed friendRulesFile
+	if err := json.Unmarshal(data, &parsed); err != nil {
+		utils.LogWarn("好友规则", fmt.Sprintf("解析 %s 失败: %v", path, err))
+		rb.markChecked(info.ModTime())
+		return
+	}
+	if parsed.Default.Mode == "" {
+		parsed.Default.Mode = FriendModeFull
+	}
+	if err := ValidateFriendMode(parsed.Default.Mode); err != nil {
+		utils.LogWarn("好友规则", err.Error())
+		rb.markChecked(info.ModTime())
+		return
+	}
+	for _, rule := range parsed.Rules {
+		if err := ValidateFriendMode(rule.Mode); err != nil {
+			utils.LogWarn("好友规则", fmt.Sprintf("%v (好友 %v)", err, rule.Friends))
+			rb.markChecked(info.ModTime())
+			return
+		}
+	}
+
+	rb.mu.Lock()
+	rb.data = parsed
+	rb.modTime = info.ModTime()
+	rb.mu.Unlock()
+	utils.Log("好友规则", fmt.Sprintf("已加载 %d 条规则, 默认 %s", len(parsed.Rules), parsed.Default.Mode))
+}
+
+// RuleFor 获取好友适用的规则 (按顺序第一条匹配的规则，没有匹配时用默认规则)
+func (rb *FriendRuleBook) RuleFor(gid int64, name string) FriendRule {
+	rb.mu.RLock()
+	defer rb.mu.RUnlock()
+	for _, rule := range rb.data.Rules {
+		if rule.matches(gid, name) {
+			return rule
+		}
+	}
+	return rb.data.Default
+}
+
+// ShouldVisit 根据规则和农场摘要判断是否拜访好友
+func (rb *FriendRuleBook) ShouldVisit(friend *friendpb.GameFriend) bool {
+	rule := rb.RuleFor(friend.GetGid(), friend.GetName())
+	if rule.Mode == FriendModeSkip {
+		return false
+	}
+	if rule.SkipInactive && isInactiveFriend(friend) {
+		return false
+	}
+	return true
+}
+
+// isInactiveFriend 农场没有作物在长也没有可偷的作物
+func isInactiveFriend(friend *friendpb.GameFriend) bool {
+	plant := friend.GetPlant()
+	return plant == nil || (plant.RipeTimeSec <= 0 && plant.StealPlantNum <= 0)
+}
@@ -0,0 +1,88 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// averageTimeReduction 已解锁土地的平均生长时间缩短buff (百分比)
+func averageTimeReduction(lands []*plantpb.LandInfo) float64 {
+	total, count := int64(0), 0
+	for _, land := range MasterLands(lands) {
+		if land == nil || !land.Unlocked {
+			continue
+		}
+		if land.Buff != nil {
+			total += land.Buff.PlantingTimeReduction
+		}
+		count++
+	}
+	if count == 0 {
+		return 0
+	}
+	return float64(total) / float64(count)
+}
+
+// effectiveGrowSec 按 grow_phases 计算生长时间，扣除土地buff缩短的部分
+func effectiveGrowSec(plant *Plant, reductionPct float64) int64 {
+	growSec := int64(Config.GetPlantGrowTime(plant.ID))
+	if reductionPct > 0 {
+		growSec -= int64(float64(growSec) * reductionPct / 100)
+	}
+	if growSec < 1 {
+		growSec = 1
+	}
+	return growSec
+}
+
+// NextReplantAt 种下后成熟的作物最早什么时候能被收获补种 (成熟时离线则等到上线, 农场暂停或只收获时等到恢复正常)
+func NextReplantAt(matureAt time.Time) time.Time {
+	at := matureAt
+	for i := 0; i < 4; i++ {
+		next := Schedule.NextFullAt(ModuleFarm, NextOnlineAt(at))
+		if next.Equal(at) {
+			break
+		}
+		at = next
+	}
+	return at
+}
+
+// pickSeedForGap 考虑离线时间段和农场作息，选择 (收获经验 / 到下次能补种的时间) 最高的种子
+// 全天在线且作息不限制农场时返回nil，由常规经验效率算法选择
+func (fm *FarmManager) pickSeedForGap(available []*SeedInfo) *SeedInfo {
+	if !HasOfflineWindows() && !Schedule.Restricts(ModuleFarm) {
+		return nil
+	}
+
+	now := time.Now()
+	reduction := averageTimeReduction(fm.GetLastLands())
+
+	var best *SeedInfo
+	var bestPlant *Plant
+	var bestReplant time.Time
+	bestRate := 0.0
+	for _, s := range available {
+		plant := Config.GetPlantBySeedID(int(s.SeedId))
+		if plant == nil || plant.Exp <= 0 {
+			continue
+		}
+		matureAt := now.Add(time.Duration(effectiveGrowSec(plant, reduction)) * time.Second)
+		replantAt := NextReplantAt(matureAt)
+		rate := float64(plant.Exp) / replantAt.Sub(now).Seconds()
+		if best == nil || rate > bestRate {
+			best, bestPlant, bestReplant, bestRate = s, plant, replantAt, rate
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	utils.Log("种植", fmt.Sprintf("按在线时间选种: %s (生长%s, 预计 %s 收获补种, 每小时 %.1f 经验/块)",
+		bestPlant.Name, FormatGrowTime(int(effectiveGrowSec(bestPlant, reduction))),
+		bestReplant.Format("01-02 15:04"), bestRate*3600))
+	return best
+}
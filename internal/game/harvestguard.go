@@ -0,0 +1,245 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// guardBatch 同一时刻成熟的一批土地
+type guardBatch struct {
+	matureMs int64
+	lands    map[int64]HarvestedLand
+	timer    *time.Timer
+}
+
+// HarvestGuard 防偷收获守卫: 按成熟时间精确触发收获
+type HarvestGuard struct {
+	batches   map[int64]*guardBatch // 成熟时间(毫秒) -> 批次
+	armed     map[int64]int64       // 土地ID -> 已布置的成熟时间(毫秒)
+	attempts  int64                 // 触发次数
+	successes int64                 // 成功次数
+	retried   int64                 // 重试后才成功的次数
+	totalLag  int64                 // 成功收获距成熟的累计延迟(毫秒)
+	mu        sync.Mutex
+}
+
+func newHarvestGuard() *HarvestGuard {
+	return &HarvestGuard{
+		batches: make(map[int64]*guardBatch),
+		armed:   make(map[int64]int64),
+	}
+}
+
+// getMatureTimeMs 获取作物成熟阶段的开始时间(毫秒)，没有成熟阶段时返回0
+func getMatureTimeMs(plant *plantpb.PlantInfo) int64 {
+	if plant == nil {
+		return 0
+	}
+	for _, phase := range plant.Phases {
+		if config.PlantPhase(phase.Phase) == config.PlantPhaseMature {
+			return utils.ToTimeMs(phase.BeginTime)
+		}
+	}
+	return 0
+}
+
+// ArmHarvestGuard 为尚未成熟的作物布置精确收获定时器
+func (fm *FarmManager) ArmHarvestGuard(lands []*plantpb.LandInfo) {
+	if !config.Current.HarvestGuard {
+		return
+	}
+
+	g := fm.guard
+	nowMs := utils.GetServerTimeMs()
+	lead := config.Current.HarvestGuardLead.Milliseconds()
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, land := range lands {
+		if land == nil || !land.Unlocked || land.Plant == nil {
+			continue
+		}
+		matureMs := getMatureTimeMs(land.Plant)
+		if matureMs <= nowMs {
+			continue // 已成熟的由常规巡查收获
+		}
+		if g.armed[land.Id] == matureMs {
+			continue
+		}
+
+		// 土地换了作物，从旧批次中移除
+		if oldMs, ok := g.armed[land.Id]; ok {
+			if old := g.batches[oldMs]; old != nil {
+				delete(old.lands, land.Id)
+				if len(old.lands) == 0 {
+					old.timer.Stop()
+					delete(g.batches, oldMs)
+				}
+			}
+		}
+
+		fruitNum := int64(0)
+		if plant := Config.GetPlantByID(int(land.Plant.Id)); plant != nil {
+			fruitNum = int64(plant.Fruit.Count)
+		}
+		harvested := HarvestedLand{
+			LandID:   land.Id,
+			PlantID:  land.Plant.Id,
+			FruitID:  land.Plant.FruitId,
+			FruitNum: fruitNum,
+		}
+
+		g.armed[land.Id] = matureMs
+		if batch, ok := g.batches[matureMs]; ok {
+			batch.lands[land.Id] = harvested
+			continue
+		}
+
+		batch := &guardBatch{
+			matureMs: matureMs,
+			lands:    map[int64]HarvestedLand{land.Id: harvested},
+		}
+		delay := time.Duration(matureMs-nowMs-lead) * time.Millisecond
+		batch.timer = time.AfterFunc(delay, func() { fm.fireHarvestGuard(batch) })
+		g.batches[matureMs] = batch
+	}
+}
+
+// fireHarvestGuard 在成熟边界触发收获，服务器提示未成熟时短暂重试
+func (fm *FarmManager) fireHarvestGuard(batch *guardBatch) {
+	g := fm.guard
+
+	g.mu.Lock()
+	delete(g.batches, batch.matureMs)
+	lands := make([]HarvestedLand, 0, len(batch.lands))
+	landIds := make([]int64, 0, len(batch.lands))
+	for landID, land := range batch.lands {
+		if g.armed[landID] == batch.matureMs {
+			delete(g.armed, landID)
+		}
+		lands = append(lands, land)
+		landIds = append(landIds, landID)
+	}
+	g.mu.Unlock()
+
+	// 农场暂停时不收获 (不计入触发次数)
+	if len(landIds) == 0 || Schedule.ModeFor(ModuleFarm) == ModePaused {
+		return
+	}
+
+	g.mu.Lock()
+	g.attempts++
+	g.mu.Unlock()
+
+	var lastErr error
+	for retry := 0; retry <= config.Current.HarvestGuardRetries; retry++ {
+		if retry > 0 {
+			time.Sleep(config.Current.HarvestGuardRetryGap)
+		}
+
+		reply, err := fm.Harvest(landIds)
+		if err != nil {
+			lastErr = err
+			if !shouldRetryGuard(err, batch.matureMs) {
+				break
+			}
+			continue
+		}
+
+		lag := utils.GetServerTimeMs() - batch.matureMs
+		g.mu.Lock()
+		g.successes++
+		g.totalLag += lag
+		if retry > 0 {
+			g.retried++
+		}
+		attempts, successes := g.attempts, g.successes
+		g.mu.Unlock()
+
+		Ledger.RecordHarvest(reply, lands, HarvestSourceOwn, 0, "")
+		Mutation.RecordHarvest(reply, lands)
+		utils.Log("防偷收获", fmt.Sprintf("成熟后 %dms 收获 %d 块地 (重试%d次) 成功率 %d/%d",
+			lag, len(landIds), retry, successes, attempts))
+		if code := network.ErrorCode(lastErr); code > 0 && len(config.Current.GuardNotReadyCodes) == 0 {
+			utils.Log("防偷收获", fmt.Sprintf("重试前的错误码 %d 应为未成熟, 可用 --guard-not-ready %d 只对它重试", code, code))
+		}
+
+		// 收获后通知巡查循环尽快补种
+		fm.RequestCheck()
+		return
+	}
+
+	g.mu.Lock()
+	attempts, successes := g.attempts, g.successes
+	g.mu.Unlock()
+	utils.LogWarn("防偷收获", fmt.Sprintf("收获 %d 块地失败: %v 成功率 %d/%d", len(landIds), lastErr, successes, attempts))
+	if code := network.ErrorCode(lastErr); code > 0 && len(config.Current.GuardNotReadyCodes) > 0 && !isNotReadyError(lastErr) {
+		utils.LogWarn("防偷收获", fmt.Sprintf("错误码 %d 不在未成熟错误码中, 没有重试 (如为未成熟可用 --guard-not-ready 添加)", code))
+	}
+}
+
+// shouldRetryGuard 收获失败后是否重试: 配置了未成熟错误码时只对这些错误重试;
+// 未配置时任何服务器错误在成熟后的重试窗口内都重试 (网络错误不重试)
+func shouldRetryGuard(err error, matureMs int64) bool {
+	if network.ErrorCode(err) <= 0 {
+		return false
+	}
+	if len(config.Current.GuardNotReadyCodes) > 0 {
+		return isNotReadyError(err)
+	}
+	window := int64(config.Current.HarvestGuardRetries+1) * config.Current.HarvestGuardRetryGap.Milliseconds()
+	return utils.GetServerTimeMs() < matureMs+window
+}
+
+// isNotReadyError 服务器是否提示作物尚未成熟 (错误码见 GuardNotReadyCodes)
+func isNotReadyError(err error) bool {
+	code := network.ErrorCode(err)
+	for _, c := range config.Current.GuardNotReadyCodes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// GetHarvestGuardStats 获取防偷收获统计 (触发次数, 成功次数, 重试后成功次数, 平均延迟毫秒)
+func (fm *FarmManager) GetHarvestGuardStats() (int64, int64, int64, int64) {
+	g := fm.guard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	avgLag := int64(0)
+	if g.successes > 0 {
+		avgLag = g.totalLag / g.successes
+	}
+	return g.attempts, g.successes, g.retried, avgLag
+}
+
+// PrintHarvestGuardSummary 输出本次运行 (含恢复的快照) 的防偷收获统计
+func (fm *FarmManager) PrintHarvestGuardSummary() {
+	attempts, successes, retried, avgLag := fm.GetHarvestGuardStats()
+	if attempts == 0 {
+		return
+	}
+	utils.Log("防偷收获", fmt.Sprintf("共触发 %d 次, 成功 %d 次 (重试后成功 %d 次), 平均延迟 %dms", attempts, successes, retried, avgLag))
+}
+
+// stopHarvestGuard 取消所有防偷收获定时器
+func (fm *FarmManager) stopHarvestGuard() {
+	g := fm.guard
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for key, batch := range g.batches {
+		batch.timer.Stop()
+		delete(g.batches, key)
+	}
+	g.armed = make(map[int64]int64)
+}
@@ -0,0 +1,418 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/corepb"
+	"gofarm/proto/gamepb/friendpb"
+	"gofarm/proto/gamepb/itempb"
+	"gofarm/proto/gamepb/plantpb"
+	"gofarm/proto/gamepb/shoppb"
+	"gofarm/proto/gamepb/taskpb"
+)
+
+// 操作日志分组方式
+const (
+	JournalGroupAction = "action"
+	JournalGroupFriend = "friend"
+	JournalGroupDate   = "date"
+)
+
+// JournalEntry 一次改变游戏状态的操作
+type JournalEntry struct {
+	Time       int64           `json:"time"`
+	Date       string          `json:"date"`
+	GID        int64           `json:"gid"`
+	Service    string          `json:"service"`
+	Method     string          `json:"method"`
+	LandIDs    []int64         `json:"landIds,omitempty"`
+	FriendGIDs []int64         `json:"friendGids,omitempty"`
+	FriendName string          `json:"friendName,omitempty"`
+	ItemIDs    []int64         `json:"itemIds,omitempty"`
+	GoodsID    int64           `json:"goodsId,omitempty"`
+	TaskIDs    []int64         `json:"taskIds,omitempty"`
+	OK         bool            `json:"ok"`
+	ErrorCode  int64           `json:"errorCode,omitempty"` // 服务器错误码, -1 表示网络/超时等非服务器错误
+	Error      string          `json:"error,omitempty"`
+	Gained     map[int64]int64 `json:"gained,omitempty"` // 物品ID -> 获得数量
+	Spent      map[int64]int64 `json:"spent,omitempty"`  // 物品ID -> 消耗数量
+}
+
+// ActionJournal 操作日志: 按天追加写入 ledger/journal-日期.jsonl
+type ActionJournal struct {
+	mu sync.Mutex
+}
+
+var Journal *ActionJournal
+
+func init() {
+	Journal = &ActionJournal{}
+}
+
+// journalFilePath 某天的操作日志文件路径
+func journalFilePath(dateKey string) string {
+	return filepath.Join(LedgerDir, fmt.Sprintf("journal-%s.jsonl", dateKey))
+}
+
+// addItems 累加物品数量
+func addItems(m map[int64]int64, items []*corepb.Item) map[int64]int64 {
+	for _, item := range items {
+		if item == nil || item.Count == 0 {
+			continue
+		}
+		if m == nil {
+			m = make(map[int64]int64)
+		}
+		m[item.Id] += item.Count
+	}
+	return m
+}
+
+// fillJournalTargets 从请求中提取操作目标
+func fillJournalTargets(entry *JournalEntry, req proto.Message) {
+	selfGID := entry.GID
+	hostTarget := func(hostGID int64) {
+		if hostGID != 0 && hostGID != selfGID {
+			entry.FriendGIDs = []int64{hostGID}
+		}
+	}
+
+	switch r := req.(type) {
+	case *plantpb.HarvestRequest:
+		entry.LandIDs = r.LandIds
+		hostTarget(r.HostGid)
+	case *plantpb.PlantRequest:
+		for _, item := range r.Items {
+			entry.LandIDs = append(entry.LandIDs, item.LandIds...)
+			entry.ItemIDs = append(entry.ItemIDs, item.SeedId)
+			entry.Spent = addItems(entry.Spent, []*corepb.Item{{Id: item.SeedId, Count: int64(len(item.LandIds))}})
+		}
+	case *plantpb.RemovePlantRequest:
+		entry.LandIDs = r.LandIds
+	case *plantpb.FertilizeRequest:
+		entry.LandIDs = r.LandIds
+		entry.ItemIDs = []int64{r.FertilizerId}
+	case *plantpb.WaterLandRequest:
+		entry.LandIDs = r.LandIds
+		hostTarget(r.HostGid)
+	case *plantpb.WeedOutRequest:
+		entry.LandIDs = r.LandIds
+		hostTarget(r.HostGid)
+	case *plantpb.InsecticideRequest:
+		entry.LandIDs = r.LandIds
+		hostTarget(r.HostGid)
+	case *plantpb.PutWeedsRequest:
+		entry.LandIDs = r.LandIds
+		hostTarget(r.HostGid)
+	case *plantpb.PutInsectsRequest:
+		entry.LandIDs = r.LandIds
+		hostTarget(r.HostGid)
+	case *shoppb.BuyGoodsRequest:
+		entry.GoodsID = r.GoodsId
+	case *itempb.SellRequest:
+		for _, item := range r.Items {
+			entry.ItemIDs = append(entry.ItemIDs, item.Id)
+		}
+	case *itempb.UseRequest:
+		entry.ItemIDs = []int64{r.ItemId}
+		entry.LandIDs = r.LandIds
+		entry.Spent = addItems(entry.Spent, []*corepb.Item{{Id: r.ItemId, Count: r.Count}})
+	case *itempb.BatchUseRequest:
+		for _, item := range r.Items {
+			entry.ItemIDs = append(entry.ItemIDs, item.ItemId)
+			entry.Spent = addItems(entry.Spent, []*corepb.Item{{Id: item.ItemId, Count: item.Count}})
+		}
+	case *taskpb.ClaimTaskRewardRequest:
+		entry.TaskIDs = []int64{r.Id}
+	case *taskpb.BatchClaimTaskRewardRequest:
+		entry.TaskIDs = r.Ids
+	case *friendpb.AcceptFriendsRequest:
+		entry.FriendGIDs = r.FriendGids
+	case *friendpb.RejectFriendsRequest:
+		entry.FriendGIDs = r.FriendGids
+	}
+
+	if len(entry.FriendGIDs) == 1 {
+		entry.FriendName = Friend.GetFriendName(entry.FriendGIDs[0])
+	}
+}
+
+// fillJournalResources 从回复中提取获得和消耗的资源
+func fillJournalResources(entry *JournalEntry, resp proto.Message) {
+	switch r := resp.(type) {
+	case *plantpb.HarvestReply:
+		entry.Gained = addItems(entry.Gained, r.Items)
+	case *shoppb.BuyGoodsReply:
+		entry.Gained = addItems(entry.Gained, r.GetItems)
+		entry.Spent = addItems(entry.Spent, r.CostItems)
+	case *itempb.SellReply:
+		entry.Gained = addItems(entry.Gained, r.GetItems)
+		entry.Spent = addItems(entry.Spent, r.SellItems)
+	case *itempb.UseReply:
+		entry.Gained = addItems(entry.Gained, r.Items)
+	case *itempb.BatchUseReply:
+		entry.Gained = addItems(entry.Gained, r.Items)
+	case *taskpb.ClaimTaskRewardReply:
+		entry.Gained = addItems(entry.Gained, r.Items)
+		entry.Gained = addItems(entry.Gained, r.CompensatedItems)
+	case *taskpb.BatchClaimTaskRewardReply:
+		entry.Gained = addItems(entry.Gained, r.Items)
+		entry.Gained = addItems(entry.Gained, r.CompensatedItems)
+	}
+}
+
+// Record 记录一次已发送的改变游戏状态的请求及其结果
+func (aj *ActionJournal) Record(serviceName, methodName string, req, resp proto.Message, err error) {
+	entry := &JournalEntry{
+		Time:    utils.GetServerTimeSec(),
+		Date:    getGameDateKey(),
+		GID:     network.Net.GetUserState().GID,
+		Service: serviceName,
+		Method:  methodName,
+		OK:      err == nil,
+	}
+	fillJournalTargets(entry, req)
+	if err != nil {
+		entry.ErrorCode = network.ErrorCode(err)
+		entry.Error = err.Error()
+		entry.Spent = nil // 失败的操作没有消耗
+	} else {
+		fillJournalResources(entry, resp)
+	}
+
+	data, jsonErr := json.Marshal(entry)
+	if jsonErr != nil {
+		return
+	}
+
+	aj.mu.Lock()
+	defer aj.mu.Unlock()
+	if err := os.MkdirAll(LedgerDir, 0755); err != nil {
+		return
+	}
+	f, openErr := os.OpenFile(journalFilePath(entry.Date), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if openErr != nil {
+		utils.LogWarn("操作日志", fmt.Sprintf("打开日志文件失败: %v", openErr))
+		return
+	}
+	defer f.Close()
+	f.Write(append(data, '\n'))
+}
+
+// JournalFilter 操作日志筛选条件 (空值表示不筛选)
+type JournalFilter struct {
+	From   string // 开始日期 (含) YYYY-MM-DD
+	To     string // 结束日期 (含) YYYY-MM-DD
+	GID    int64  // 账号
+	Action string // 方法名, 如 Harvest/Sell (不区分大小写)
+	Friend string // 好友GID或昵称
+	Failed bool   // 只看失败的操作
+}
+
+// matches 日志是否满足筛选条件
+func (f *JournalFilter) matches(entry *JournalEntry) bool {
+	if f.GID != 0 && entry.GID != f.GID {
+		return false
+	}
+	if f.Action != "" && !strings.EqualFold(entry.Method, f.Action) {
+		return false
+	}
+	if f.Failed && entry.OK {
+		return false
+	}
+	if f.Friend != "" {
+		found := entry.FriendName == f.Friend
+		for _, gid := range entry.FriendGIDs {
+			if fmt.Sprint(gid) == f.Friend {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// LoadJournal 读取日期范围内满足条件的操作日志
+func LoadJournal(filter JournalFilter) ([]*JournalEntry, error) {
+	paths, err := filepath.Glob(filepath.Join(LedgerDir, "journal-*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	var entries []*JournalEntry
+	for _, path := range paths {
+		dateKey := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(path), "journal-"), ".jsonl")
+		if (filter.From != "" && dateKey < filter.From) || (filter.To != "" && dateKey > filter.To) {
+			continue
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry JournalEntry
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				continue
+			}
+			if filter.matches(&entry) {
+				entries = append(entries, &entry)
+			}
+		}
+		f.Close()
+	}
+	return entries, nil
+}
+
+// JournalGroup 按分组汇总的操作日志
+type JournalGroup struct {
+	Key    string
+	Count  int64
+	Failed int64
+	Lands  int64
+	Gained map[int64]int64
+	Spent  map[int64]int64
+}
+
+// journalGroupKeys 日志所属的分组
+func journalGroupKeys(entry *JournalEntry, groupBy string) []string {
+	switch groupBy {
+	case JournalGroupDate:
+		return []string{entry.Date}
+	case JournalGroupFriend:
+		if len(entry.FriendGIDs) == 0 {
+			return []string{"(自己)"}
+		}
+		keys := make([]string, 0, len(entry.FriendGIDs))
+		for _, gid := range entry.FriendGIDs {
+			if entry.FriendName != "" && len(entry.FriendGIDs) == 1 {
+				keys = append(keys, fmt.Sprintf("%s (GID:%d)", entry.FriendName, gid))
+			} else {
+				keys = append(keys, fmt.Sprintf("GID:%d", gid))
+			}
+		}
+		return keys
+	default:
+		return []string{entry.Method}
+	}
+}
+
+// AggregateJournal 按操作/好友/日期汇总操作日志，按次数降序
+func AggregateJournal(entries []*JournalEntry, groupBy string) []*JournalGroup {
+	groups := make(map[string]*JournalGroup)
+	for _, entry := range entries {
+		for _, key := range journalGroupKeys(entry, groupBy) {
+			group, ok := groups[key]
+			if !ok {
+				group = &JournalGroup{Key: key, Gained: make(map[int64]int64), Spent: make(map[int64]int64)}
+				groups[key] = group
+			}
+			group.Count++
+			if !entry.OK {
+				group.Failed++
+			}
+			group.Lands += int64(len(entry.LandIDs))
+			for id, count := range entry.Gained {
+				group.Gained[id] += count
+			}
+			for id, count := range entry.Spent {
+				group.Spent[id] += count
+			}
+		}
+	}
+
+	result := make([]*JournalGroup, 0, len(groups))
+	for _, group := range groups {
+		result = append(result, group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if groupBy == JournalGroupDate {
+			return result[i].Key < result[j].Key
+		}
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// formatItemCounts 格式化物品数量, 按物品ID排序
+func formatItemCounts(items map[int64]int64) string {
+	ids := make([]int64, 0, len(items))
+	for id := range items {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	parts := make([]string, 0, len(ids))
+	for _, id := range ids {
+		parts = append(parts, fmt.Sprintf("%s x%d", Config.GetItemName(int(id)), items[id]))
+	}
+	return strings.Join(parts, " ")
+}
+
+// PrintJournalEntries 逐条打印操作日志
+func PrintJournalEntries(entries []*JournalEntry) {
+	for _, entry := range entries {
+		line := fmt.Sprintf("%s %s", time.Unix(entry.Time, 0).Format("2006-01-02 15:04:05"), entry.Method)
+		if entry.FriendName != "" {
+			line += " @" + entry.FriendName
+		} else if len(entry.FriendGIDs) > 0 {
+			line += fmt.Sprintf(" @%v", entry.FriendGIDs)
+		}
+		if len(entry.LandIDs) > 0 {
+			line += fmt.Sprintf(" 土地%v", entry.LandIDs)
+		}
+		if len(entry.TaskIDs) > 0 {
+			line += fmt.Sprintf(" 任务%v", entry.TaskIDs)
+		}
+		if !entry.OK {
+			line += fmt.Sprintf(" 失败(code=%d)", entry.ErrorCode)
+		}
+		if len(entry.Gained) > 0 {
+			line += " +" + formatItemCounts(entry.Gained)
+		}
+		if len(entry.Spent) > 0 {
+			line += " -" + formatItemCounts(entry.Spent)
+		}
+		fmt.Println(line)
+	}
+}
+
+// PrintJournalReport 打印操作日志汇总
+func PrintJournalReport(entries []*JournalEntry, groupBy string) {
+	fmt.Printf("共 %d 条操作\n", len(entries))
+	for _, group := range AggregateJournal(entries, groupBy) {
+		line := fmt.Sprintf("  %-24s %5d 次", group.Key, group.Count)
+		if group.Failed > 0 {
+			line += fmt.Sprintf(" (失败 %d)", group.Failed)
+		}
+		if group.Lands > 0 {
+			line += fmt.Sprintf(" 土地 %d 块", group.Lands)
+		}
+		fmt.Println(line)
+		if len(group.Gained) > 0 {
+			fmt.Println("      获得: " + formatItemCounts(group.Gained))
+		}
+		if len(group.Spent) > 0 {
+			fmt.Println("      消耗: " + formatItemCounts(group.Spent))
+		}
+	}
+}
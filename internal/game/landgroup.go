@@ -0,0 +1,180 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// LandGroup 共享土地组: 大作物占用一块主地和若干副地，操作只针对主地
+type LandGroup struct {
+	MasterID int64
+	SlaveIDs []int64
+	Size     int64 // 土地组尺寸 (land_size)
+}
+
+// isSlaveLand 是否为从属于其他主地的副地
+func isSlaveLand(land *plantpb.LandInfo) bool {
+	return land.MasterLandId != 0 && land.MasterLandId != land.Id
+}
+
+// BuildLandGroups 根据 master_land_id / slave_land_ids 构建共享土地组 (主地ID -> 土地组)
+func BuildLandGroups(lands []*plantpb.LandInfo) map[int64]*LandGroup {
+	groups := make(map[int64]*LandGroup)
+	getGroup := func(masterID int64) *LandGroup {
+		group, ok := groups[masterID]
+		if !ok {
+			group = &LandGroup{MasterID: masterID}
+			groups[masterID] = group
+		}
+		return group
+	}
+
+	for _, land := range lands {
+		if land == nil || !land.Unlocked {
+			continue
+		}
+		if isSlaveLand(land) {
+			group := getGroup(land.MasterLandId)
+			if !containsInt64(group.SlaveIDs, land.Id) {
+				group.SlaveIDs = append(group.SlaveIDs, land.Id)
+			}
+			continue
+		}
+		if len(land.SlaveLandIds) == 0 {
+			continue
+		}
+		group := getGroup(land.Id)
+		group.Size = land.LandSize
+		for _, slaveID := range land.SlaveLandIds {
+			if slaveID != land.Id && !containsInt64(group.SlaveIDs, slaveID) {
+				group.SlaveIDs = append(group.SlaveIDs, slaveID)
+			}
+		}
+	}
+
+	for _, group := range groups {
+		sort.Slice(group.SlaveIDs, func(i, j int) bool { return group.SlaveIDs[i] < group.SlaveIDs[j] })
+	}
+	return groups
+}
+
+// slaveLandSet 所有副地的ID集合
+func slaveLandSet(groups map[int64]*LandGroup) map[int64]bool {
+	result := make(map[int64]bool)
+	for _, group := range groups {
+		for _, slaveID := range group.SlaveIDs {
+			result[slaveID] = true
+		}
+	}
+	return result
+}
+
+// MasterLands 过滤掉副地，只保留主地和独立土地
+func MasterLands(lands []*plantpb.LandInfo) []*plantpb.LandInfo {
+	slaves := slaveLandSet(BuildLandGroups(lands))
+	if len(slaves) == 0 {
+		return lands
+	}
+
+	result := make([]*plantpb.LandInfo, 0, len(lands))
+	for _, land := range lands {
+		if land != nil && slaves[land.Id] {
+			continue
+		}
+		result = append(result, land)
+	}
+	return result
+}
+
+// CountPlots 统计可独立种植的地块数 (共享土地组算一块)
+func CountPlots(lands []*plantpb.LandInfo) int {
+	count := 0
+	for _, land := range MasterLands(lands) {
+		if land != nil && land.Unlocked {
+			count++
+		}
+	}
+	return count
+}
+
+// FormatLandGroups 格式化共享土地组 (用于状态输出)，如 "#1+#2+#3"
+func FormatLandGroups(groups map[int64]*LandGroup) []string {
+	masters := make([]int64, 0, len(groups))
+	for masterID := range groups {
+		masters = append(masters, masterID)
+	}
+	sort.Slice(masters, func(i, j int) bool { return masters[i] < masters[j] })
+
+	result := make([]string, 0, len(masters))
+	for _, masterID := range masters {
+		text := fmt.Sprintf("#%d", masterID)
+		for _, slaveID := range groups[masterID].SlaveIDs {
+			text += fmt.Sprintf("+#%d", slaveID)
+		}
+		result = append(result, text)
+	}
+	return result
+}
+
+// slaveIDsFromReply 从种植回复中找出新成为副地的土地
+func slaveIDsFromReply(lands []*plantpb.LandInfo) []int64 {
+	var result []int64
+	for _, group := range BuildLandGroups(lands) {
+		result = append(result, group.SlaveIDs...)
+	}
+	return result
+}
+
+// seedLandSize 种子作物的占地尺寸 (普通作物为1)
+func seedLandSize(seedID int64) int {
+	if plant := Config.GetPlantBySeedID(int(seedID)); plant != nil && plant.Size > 1 {
+		return plant.Size
+	}
+	return 1
+}
+
+// canShareLand 土地能否作为大作物的一部分 (没有土地信息时按可以处理)
+func canShareLand(land *plantpb.LandInfo) bool {
+	return land == nil || land.CanShare || land.IsShared
+}
+
+// coverLands 按顺序计算 seeds 颗种子能种满前多少块地:
+// 大作物在可共享的土地上每颗种子占用 尺寸 块地 (主地+自动占用的副地)，不可共享的土地每块一颗
+func coverLands(seedID int64, landIds []int64, lands map[int64]*plantpb.LandInfo, seeds int64) int {
+	size := seedLandSize(seedID)
+	used := int64(0)
+	shared := 0
+	for i, landID := range landIds {
+		if size > 1 && canShareLand(lands[landID]) {
+			if shared%size == 0 {
+				used++
+			}
+			shared++
+		} else {
+			used++
+		}
+		if used > seeds {
+			return i
+		}
+	}
+	return len(landIds)
+}
+
+// seedsForLands 种满这些土地需要的种子数 (按可共享土地估算，实际副地由服务器分配)
+func seedsForLands(seedID int64, landIds []int64, lands map[int64]*plantpb.LandInfo) int64 {
+	size := seedLandSize(seedID)
+	if size <= 1 {
+		return int64(len(landIds))
+	}
+	shared, single := 0, 0
+	for _, landID := range landIds {
+		if canShareLand(lands[landID]) {
+			shared++
+		} else {
+			single++
+		}
+	}
+	return int64((shared+size-1)/size + single)
+}
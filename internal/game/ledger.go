@@ -0,0 +1,342 @@
+package game
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/corepb"
+	"gofarm/proto/gamepb/plantpb"
+	"gofarm/tools"
+)
+
+// 种植经验的物品 ID
+const ExpItemID = 1101
+
+// 收获来源
+const (
+	HarvestSourceOwn   = "own"   // 收获自己的作物
+	HarvestSourceSteal = "steal" // 偷好友的作物
+)
+
+// 收获账本目录
+const LedgerDir = "ledger"
+
+// HarvestedLand 被收获的土地 (收获前的状态)
+type HarvestedLand struct {
+	LandID   int64
+	PlantID  int64
+	FruitID  int64
+	FruitNum int64 // 收获前剩余果实数
+}
+
+// harvestedLandsFromInfo 从可收获植物信息构建收获土地列表
+func harvestedLandsFromInfo(infos []HarvestablePlant) []HarvestedLand {
+	lands := make([]HarvestedLand, 0, len(infos))
+	for _, info := range infos {
+		lands = append(lands, HarvestedLand{
+			LandID:   info.LandID,
+			PlantID:  info.PlantID,
+			FruitID:  info.FruitID,
+			FruitNum: info.FruitNum,
+		})
+	}
+	return lands
+}
+
+// HarvestRecord 单块地的收获记录
+type HarvestRecord struct {
+	Time       int64           `json:"time"`
+	GID        int64           `json:"gid"`
+	Source     string          `json:"source"`
+	FriendGID  int64           `json:"friendGid,omitempty"`
+	FriendName string          `json:"friendName,omitempty"`
+	LandID     int64           `json:"landId"`
+	PlantID    int64           `json:"plantId"`
+	Items      map[int64]int64 `json:"items"`
+	LostItems  map[int64]int64 `json:"lostItems,omitempty"`
+}
+
+// CropYield 按作物和来源汇总的收益
+type CropYield struct {
+	PlantID  int64
+	Name     string
+	Source   string
+	Harvests int64 // 收获地块数
+	Fruit    int64
+	Exp      int64
+	Lost     int64 // 被偷走的果实数
+}
+
+// HarvestLedger 收获账本: 明细只追加到文件, 内存中只保留按作物和来源的汇总
+type HarvestLedger struct {
+	yields map[string]*CropYield // "作物ID:来源" -> 汇总
+	mu     sync.RWMutex
+}
+
+var Ledger *HarvestLedger
+
+func init() {
+	Ledger = &HarvestLedger{
+		yields: make(map[string]*CropYield),
+	}
+}
+
+// distributeItems 把回复中的物品分摊到各块地：
+// 果实按收获前剩余果实数分给对应作物的地，其他物品平均分给所有地
+func distributeItems(items []*corepb.Item, lands []HarvestedLand) []map[int64]int64 {
+	result := make([]map[int64]int64, len(lands))
+	for i := range result {
+		result[i] = make(map[int64]int64)
+	}
+	if len(lands) == 0 {
+		return result
+	}
+
+	for _, item := range items {
+		if item == nil || item.Count <= 0 {
+			continue
+		}
+
+		var targets []int
+		var weights []int64
+		totalWeight := int64(0)
+		for i, land := range lands {
+			if land.FruitID == item.Id {
+				weight := land.FruitNum
+				if weight <= 0 {
+					weight = 1
+				}
+				targets = append(targets, i)
+				weights = append(weights, weight)
+				totalWeight += weight
+			}
+		}
+		if len(targets) == 0 {
+			for i := range lands {
+				targets = append(targets, i)
+				weights = append(weights, 1)
+			}
+			totalWeight = int64(len(lands))
+		}
+
+		// 按权重分配，余数给第一块地
+		assigned := int64(0)
+		for k, i := range targets {
+			share := item.Count * weights[k] / totalWeight
+			result[i][item.Id] += share
+			assigned += share
+		}
+		result[targets[0]][item.Id] += item.Count - assigned
+	}
+
+	return result
+}
+
+// RecordHarvest 记录一次收获/偷菜的收益
+func (hl *HarvestLedger) RecordHarvest(reply *plantpb.HarvestReply, lands []HarvestedLand, source string, friendGID int64, friendName string) {
+	// 模拟模式下没有真实收益
+	if reply == nil || len(lands) == 0 || IsDryRun() {
+		return
+	}
+
+	gained := distributeItems(reply.Items, lands)
+	lost := distributeItems(reply.LostItems, lands)
+	now := utils.GetServerTimeSec()
+	gid := network.Net.GetUserState().GID
+
+	records := make([]*HarvestRecord, 0, len(lands))
+	for i, land := range lands {
+		rec := &HarvestRecord{
+			Time:       now,
+			GID:        gid,
+			Source:     source,
+			FriendGID:  friendGID,
+			FriendName: friendName,
+			LandID:     land.LandID,
+			PlantID:    land.PlantID,
+			Items:      gained[i],
+		}
+		if len(lost[i]) > 0 {
+			rec.LostItems = lost[i]
+		}
+		records = append(records, rec)
+	}
+
+	hl.mu.Lock()
+	for _, rec := range records {
+		hl.add(rec)
+	}
+	hl.mu.Unlock()
+
+	hl.appendToFile(gid, records)
+}
+
+// add 把一条记录计入汇总 (调用方持有锁)
+func (hl *HarvestLedger) add(rec *HarvestRecord) {
+	key := fmt.Sprintf("%d:%s", rec.PlantID, rec.Source)
+	y, ok := hl.yields[key]
+	if !ok {
+		y = &CropYield{
+			PlantID: rec.PlantID,
+			Name:    Config.GetPlantName(int(rec.PlantID)),
+			Source:  rec.Source,
+		}
+		hl.yields[key] = y
+	}
+
+	y.Harvests++
+	y.Exp += rec.Items[ExpItemID]
+	if plant := Config.GetPlantByID(int(rec.PlantID)); plant != nil {
+		y.Fruit += rec.Items[int64(plant.Fruit.ID)]
+		y.Lost += rec.LostItems[int64(plant.Fruit.ID)]
+	}
+}
+
+// ledgerFilePath 账号的账本文件路径
+func ledgerFilePath(gid int64) string {
+	return filepath.Join(LedgerDir, fmt.Sprintf("harvest-%d.jsonl", gid))
+}
+
+// legacyLedgerFilePath 按账号分开之前所有账号共用的账本文件 (记录中带有GID)
+func legacyLedgerFilePath() string {
+	return filepath.Join(LedgerDir, "harvest.jsonl")
+}
+
+// appendToFile 追加记录到账本文件 (每行一条JSON)
+func (hl *HarvestLedger) appendToFile(gid int64, records []*HarvestRecord) {
+	if err := os.MkdirAll(LedgerDir, 0755); err != nil {
+		utils.LogWarn("账本", fmt.Sprintf("创建账本目录失败: %v", err))
+		return
+	}
+
+	f, err := os.OpenFile(ledgerFilePath(gid), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		utils.LogWarn("账本", fmt.Sprintf("打开账本文件失败: %v", err))
+		return
+	}
+	defer f.Close()
+
+	for _, rec := range records {
+		data, err := json.Marshal(rec)
+		if err != nil {
+			continue
+		}
+		f.Write(append(data, '\n'))
+	}
+}
+
+// LoadFromFile 从账本文件重新汇总账号的历史收益 (gid 为0时汇总所有账号)
+func (hl *HarvestLedger) LoadFromFile(gid int64) error {
+	var paths []string
+	if gid == 0 {
+		paths, _ = filepath.Glob(filepath.Join(LedgerDir, "harvest-*.jsonl"))
+	} else {
+		paths = []string{ledgerFilePath(gid)}
+	}
+	paths = append(paths, legacyLedgerFilePath())
+
+	hl.mu.Lock()
+	defer hl.mu.Unlock()
+	hl.yields = make(map[string]*CropYield)
+
+	found := false
+	for _, path := range paths {
+		err := hl.loadFile(path, gid)
+		if os.IsNotExist(err) {
+			continue
+		}
+		found = true
+		if err != nil {
+			return err
+		}
+	}
+	if !found {
+		return os.ErrNotExist
+	}
+	return nil
+}
+
+// loadFile 逐行读取账本文件并计入汇总，gid 不为0时只计该账号的记录 (调用方持有锁)
+func (hl *HarvestLedger) loadFile(path string, gid int64) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec HarvestRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if gid != 0 && rec.GID != gid {
+			continue
+		}
+		hl.add(&rec)
+	}
+	return scanner.Err()
+}
+
+// GetCropYields 按作物和来源汇总收益
+func (hl *HarvestLedger) GetCropYields() []*CropYield {
+	hl.mu.RLock()
+	yields := make([]*CropYield, 0, len(hl.yields))
+	for _, y := range hl.yields {
+		copied := *y
+		yields = append(yields, &copied)
+	}
+	hl.mu.RUnlock()
+
+	sort.Slice(yields, func(i, j int) bool {
+		if yields[i].PlantID != yields[j].PlantID {
+			return yields[i].PlantID < yields[j].PlantID
+		}
+		return yields[i].Source < yields[j].Source
+	})
+	return yields
+}
+
+// PrintLedgerReport 打印实际收益与理论值 (tools.CalculateSeedExp) 的对比
+func (hl *HarvestLedger) PrintLedgerReport(lands int) {
+	yields := hl.GetCropYields()
+	if len(yields) == 0 {
+		fmt.Println("\n收获账本为空")
+		return
+	}
+
+	// 理论值: 优先使用经验分析数据，缺失时回退到 Plant.json
+	theory := make(map[int64]*tools.SeedExpInfo)
+	for _, seed := range tools.CalculateSeedExp(lands) {
+		theory[seed.PlantID] = seed
+	}
+
+	fmt.Printf("\n========== 收获账本 (实际 vs 理论) ==========\n")
+	fmt.Printf("%-12s %-6s %-6s %-10s %-10s %-10s %-10s %-8s\n",
+		"作物", "来源", "次数", "平均果实", "理论果实", "平均经验", "理论经验", "被偷")
+	for _, y := range yields {
+		theoryFruit, theoryExp := int64(0), int64(0)
+		if seed, ok := theory[y.PlantID]; ok {
+			theoryFruit, theoryExp = seed.FruitCount, seed.ExpHarvest
+		} else if plant := Config.GetPlantByID(int(y.PlantID)); plant != nil {
+			theoryFruit, theoryExp = int64(plant.Fruit.Count), int64(plant.Exp)
+		}
+
+		source := "自己"
+		if y.Source == HarvestSourceSteal {
+			source = "偷菜"
+		}
+		fmt.Printf("%-12s %-6s %-6d %-10.2f %-10d %-10.2f %-10d %-8d\n",
+			y.Name, source, y.Harvests,
+			float64(y.Fruit)/float64(y.Harvests), theoryFruit,
+			float64(y.Exp)/float64(y.Harvests), theoryExp, y.Lost)
+	}
+	fmt.Println("=============================================")
+}
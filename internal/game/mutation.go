@@ -0,0 +1,561 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+
+	"gofarm/internal/config"
+	"gofarm/internal/utils"
+	"gofarm/proto/corepb"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// MutationRecord 一次作物变异记录
+type MutationRecord struct {
+	Time           int64           `json:"time"`
+	LandID         int64           `json:"landId"`
+	PlantKey       string          `json:"plantKey"`
+	PlantID        int64           `json:"plantId"`
+	PlantName      string          `json:"plantName"`
+	MutantConfigID int64           `json:"mutantConfigId"`
+	MutantName     string          `json:"mutantName"`
+	MutantTime     int64           `json:"mutantTime,omitempty"`
+	WeatherID      int64           `json:"weatherId"`
+	Harvested      map[int64]int64 `json:"harvested,omitempty"` // 收获到的物品
+	Died           bool            `json:"died,omitempty"`      // 枯死未收获, 已铲除
+}
+
+// mutationState 变异追踪的持久化数据
+type mutationState struct {
+	Observed  map[int64]int64   `json:"observed"`  // 植物ID -> 观察到的种植次数
+	History   []*MutationRecord `json:"history"`   // 变异历史
+	KeptFruit map[int64]int64   `json:"keptFruit"` // 变异果实ID -> 需保留的数量 (变异果实离开背包后释放)
+}
+
+// activeMutant 土地上的变异作物 (作物被铲除或换种前一直保留)
+type activeMutant struct {
+	plantKey  string
+	plantID   int64
+	configIDs []int64
+	harvested bool // 已收获，可以铲除
+	dead      bool // 已枯死但未收获 (无法再收获, 记入历史后允许铲除)
+	warned    bool // 已提示跳过铲除
+}
+
+// MutationStats 单种作物的变异统计
+type MutationStats struct {
+	PlantID   int64
+	Name      string
+	Observed  int64            // 观察到的种植次数
+	Mutations int64            // 变异次数
+	ByWeather map[int64]int64  // 天气ID -> 变异次数
+	ByMutant  map[string]int64 // 变异名称 -> 次数
+}
+
+// MutationTracker 作物变异和天气追踪
+type MutationTracker struct {
+	gid      int64
+	state    mutationState
+	landKeys map[int64]string // 土地ID -> 当前作物键
+	active   map[int64]*activeMutant
+	seen     map[string]bool // 已记录的变异
+	loaded   bool
+	mu       sync.Mutex
+}
+
+var Mutation *MutationTracker
+
+func init() {
+	Mutation = &MutationTracker{
+		state: mutationState{
+			Observed:  make(map[int64]int64),
+			KeptFruit: make(map[int64]int64),
+		},
+		landKeys: make(map[int64]string),
+		active:   make(map[int64]*activeMutant),
+		seen:     make(map[string]bool),
+	}
+}
+
+// mutationFilePath 账号的变异数据文件路径
+func mutationFilePath(gid int64) string {
+	return filepath.Join(LedgerDir, fmt.Sprintf("mutation-%d.json", gid))
+}
+
+// readMutationState 读取变异数据文件
+func readMutationState(path string) (*mutationState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state mutationState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析变异数据失败: %v", err)
+	}
+	if state.Observed == nil {
+		state.Observed = make(map[int64]int64)
+	}
+	if state.KeptFruit == nil {
+		state.KeptFruit = make(map[int64]int64)
+	}
+	return &state, nil
+}
+
+// mutationSeenKey 变异去重键
+func mutationSeenKey(landID int64, plantKey string, configID int64) string {
+	return fmt.Sprintf("%d:%s:%d", landID, plantKey, configID)
+}
+
+// ensureLoaded 首次使用或切换账号时加载该账号的历史数据 (调用方持有锁)
+func (mt *MutationTracker) ensureLoaded() {
+	gid := currentGID()
+	if mt.loaded && mt.gid == gid {
+		return
+	}
+	mt.loaded = true
+	mt.gid = gid
+	mt.state = mutationState{
+		Observed:  make(map[int64]int64),
+		KeptFruit: make(map[int64]int64),
+	}
+	mt.landKeys = make(map[int64]string)
+	mt.active = make(map[int64]*activeMutant)
+	mt.seen = make(map[string]bool)
+	if gid == 0 {
+		return
+	}
+
+	state, err := readMutationState(mutationFilePath(gid))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogWarn("变异", err.Error())
+		}
+		return
+	}
+	mt.state = *state
+	for _, rec := range state.History {
+		mt.seen[mutationSeenKey(rec.LandID, rec.PlantKey, rec.MutantConfigID)] = true
+	}
+}
+
+// save 保存变异数据 (调用方持有锁)
+func (mt *MutationTracker) save() {
+	if mt.gid == 0 {
+		return
+	}
+	data, err := json.MarshalIndent(mt.state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(LedgerDir, 0755); err != nil {
+		return
+	}
+	if err := os.WriteFile(mutationFilePath(mt.gid), data, 0644); err != nil {
+		utils.LogWarn("变异", fmt.Sprintf("保存变异数据失败: %v", err))
+	}
+}
+
+// collectMutants 收集作物的所有变异信息 (阶段中带天气的优先)
+func collectMutants(plant *plantpb.PlantInfo) []*plantpb.MutantInfo {
+	var result []*plantpb.MutantInfo
+	found := make(map[int64]bool)
+	for _, phase := range plant.Phases {
+		for _, mutant := range phase.Mutants {
+			if mutant == nil || found[mutant.MutantConfigId] {
+				continue
+			}
+			found[mutant.MutantConfigId] = true
+			result = append(result, mutant)
+		}
+	}
+	for _, configID := range plant.MutantConfigIds {
+		if !found[configID] {
+			found[configID] = true
+			result = append(result, &plantpb.MutantInfo{MutantConfigId: configID})
+		}
+	}
+	return result
+}
+
+// isPlantDead 作物是否已枯死
+func isPlantDead(plant *plantpb.PlantInfo) bool {
+	phase := Farm.getCurrentPhase(plant.Phases, utils.GetServerTimeSec())
+	return phase != nil && config.PlantPhase(phase.Phase) == config.PlantPhaseDead
+}
+
+// ObserveLands 检测自家土地上的变异作物并记录变异历史
+func (mt *MutationTracker) ObserveLands(lands []*plantpb.LandInfo) {
+	now := utils.GetServerTimeSec()
+
+	mt.mu.Lock()
+	mt.ensureLoaded()
+
+	changed := false
+	var newRecords []*MutationRecord
+	for _, land := range lands {
+		if land == nil || !land.Unlocked {
+			continue
+		}
+		plant := land.Plant
+		if plant == nil || len(plant.Phases) == 0 {
+			delete(mt.landKeys, land.Id)
+			delete(mt.active, land.Id)
+			continue
+		}
+
+		key := plantKeyOf(plant)
+		if mt.landKeys[land.Id] != key {
+			mt.landKeys[land.Id] = key
+			mt.state.Observed[plant.Id]++
+			delete(mt.active, land.Id)
+			changed = true
+		}
+
+		mutants := collectMutants(plant)
+		if len(mutants) == 0 {
+			continue
+		}
+
+		// 同一株作物保留收获/提示状态，枯死的变异作物留在集合中, 铲除时记入历史后移出
+		am, ok := mt.active[land.Id]
+		if !ok {
+			am = &activeMutant{plantKey: key, plantID: plant.Id}
+		}
+		am.configIDs = am.configIDs[:0]
+		am.dead = isPlantDead(plant)
+		for _, mutant := range mutants {
+			am.configIDs = append(am.configIDs, mutant.MutantConfigId)
+
+			seenKey := mutationSeenKey(land.Id, key, mutant.MutantConfigId)
+			if mt.seen[seenKey] {
+				continue
+			}
+			mt.seen[seenKey] = true
+
+			rec := &MutationRecord{
+				Time:           now,
+				LandID:         land.Id,
+				PlantKey:       key,
+				PlantID:        plant.Id,
+				PlantName:      Config.GetPlantName(int(plant.Id)),
+				MutantConfigID: mutant.MutantConfigId,
+				MutantName:     Config.GetMutantName(int(plant.Id), int(mutant.MutantConfigId)),
+				MutantTime:     utils.ToTimeSec(mutant.MutantTime),
+				WeatherID:      mutant.WeatherId,
+			}
+			mt.state.History = append(mt.state.History, rec)
+			newRecords = append(newRecords, rec)
+			changed = true
+		}
+		mt.active[land.Id] = am
+	}
+
+	if changed {
+		mt.save()
+	}
+	mt.mu.Unlock()
+
+	for _, rec := range newRecords {
+		weather := "未知天气"
+		if rec.WeatherID > 0 {
+			weather = fmt.Sprintf("天气%d", rec.WeatherID)
+		}
+		utils.Log("变异", fmt.Sprintf("土地#%d 的%s 发生变异: %s (%s)", rec.LandID, rec.PlantName, rec.MutantName, weather))
+	}
+}
+
+// SplitMutantLands 把土地分成未收获的变异作物和其他作物两组
+func (mt *MutationTracker) SplitMutantLands(landIds []int64) (mutants, others []int64) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	for _, landID := range landIds {
+		if am, ok := mt.active[landID]; ok && !am.harvested {
+			mutants = append(mutants, landID)
+		} else {
+			others = append(others, landID)
+		}
+	}
+	return mutants, others
+}
+
+// FilterRemovable 过滤掉有未收获变异作物的土地，防止被铲除；
+// 已枯死的变异作物无法再收获，记入变异历史后照常铲除
+func (mt *MutationTracker) FilterRemovable(landIds []int64) []int64 {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+
+	removable := make([]int64, 0, len(landIds))
+	died := false
+	for _, landID := range landIds {
+		am, ok := mt.active[landID]
+		if !ok || am.harvested {
+			removable = append(removable, landID)
+			continue
+		}
+		if am.dead {
+			for _, rec := range mt.state.History {
+				if rec.LandID == landID && rec.PlantKey == am.plantKey {
+					rec.Died = true
+				}
+			}
+			delete(mt.active, landID)
+			died = true
+			removable = append(removable, landID)
+			utils.LogWarn("变异", fmt.Sprintf("土地#%d 的变异作物%s 已枯死未收获，已记入变异历史并铲除",
+				landID, Config.GetPlantName(int(am.plantID))))
+			continue
+		}
+		if !am.warned {
+			am.warned = true
+			utils.LogWarn("变异", fmt.Sprintf("土地#%d 的变异作物%s 尚未收获，跳过铲除",
+				landID, Config.GetPlantName(int(am.plantID))))
+		}
+	}
+	if died {
+		mt.save()
+	}
+	return removable
+}
+
+// mutantFruitIDs 变异后植物的果实ID (来自 Plant.json 的 mutant 配置)
+func mutantFruitIDs(plantID int64, configIDs []int64) map[int64]bool {
+	result := make(map[int64]bool)
+	mutants := Config.GetPlantMutants(int(plantID))
+	for _, configID := range configIDs {
+		if target := Config.GetPlantByID(mutants[int(configID)]); target != nil && target.Fruit.ID > 0 {
+			result[int64(target.Fruit.ID)] = true
+		}
+	}
+	return result
+}
+
+// RecordHarvest 记录变异作物的收获，变异果实计入保留数量 (普通果实照常出售)
+func (mt *MutationTracker) RecordHarvest(reply *plantpb.HarvestReply, lands []HarvestedLand) {
+	if reply == nil || len(lands) == 0 || IsDryRun() {
+		return
+	}
+	gained := distributeItems(reply.Items, lands)
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.ensureLoaded()
+
+	// 变异果实: 配置中变异后植物的果实，配置缺失时为收获的土地都不产出的果实
+	normalFruit := make(map[int64]bool, len(lands))
+	for _, land := range lands {
+		normalFruit[land.FruitID] = true
+	}
+	mutantFruit := make(map[int64]bool)
+	changed := false
+	for i, land := range lands {
+		am, ok := mt.active[land.LandID]
+		if !ok || am.harvested {
+			continue
+		}
+		am.harvested = true
+		changed = true
+
+		for itemID := range mutantFruitIDs(am.plantID, am.configIDs) {
+			mutantFruit[itemID] = true
+		}
+		for itemID := range gained[i] {
+			if !normalFruit[itemID] && Warehouse.isFruitID(itemID) {
+				mutantFruit[itemID] = true
+			}
+		}
+		for _, rec := range mt.state.History {
+			if rec.LandID == land.LandID && rec.PlantKey == am.plantKey {
+				rec.Harvested = gained[i]
+			}
+		}
+		utils.Log("变异", fmt.Sprintf("收获变异作物 土地#%d %s → %s",
+			land.LandID, Config.GetPlantName(int(am.plantID)), Task.formatRewardItems(mapToItems(gained[i]))))
+	}
+	for _, item := range reply.Items {
+		if item != nil && item.Count > 0 && mutantFruit[item.Id] {
+			mt.state.KeptFruit[item.Id] += item.Count
+		}
+	}
+
+	if changed {
+		mt.save()
+	}
+}
+
+// mapToItems 物品映射转为物品列表
+func mapToItems(m map[int64]int64) []*corepb.Item {
+	items := make([]*corepb.Item, 0, len(m))
+	for id, count := range m {
+		items = append(items, &corepb.Item{Id: id, Count: count})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].Id < items[j].Id })
+	return items
+}
+
+// SellableItem 扣除变异果实保留数量后可出售的物品，全部需要保留时返回nil
+func (mt *MutationTracker) SellableItem(item *corepb.Item) *corepb.Item {
+	if !config.Current.KeepMutantFruit {
+		return item
+	}
+
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.ensureLoaded()
+
+	kept := mt.state.KeptFruit[item.Id]
+	if kept <= 0 {
+		return item
+	}
+	if kept >= item.Count {
+		return nil
+	}
+
+	sellable := proto.Clone(item).(*corepb.Item)
+	sellable.Count = item.Count - kept
+	return sellable
+}
+
+// SyncBag 按背包数量释放保留的变异果实 (已出售/使用或离开背包的部分不再保留)
+func (mt *MutationTracker) SyncBag(items []*corepb.Item) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	mt.ensureLoaded()
+
+	inBag := make(map[int64]int64)
+	for _, item := range items {
+		if item != nil {
+			inBag[item.Id] += item.Count
+		}
+	}
+	changed := false
+	for itemID, kept := range mt.state.KeptFruit {
+		if count := inBag[itemID]; count < kept {
+			if count <= 0 {
+				delete(mt.state.KeptFruit, itemID)
+			} else {
+				mt.state.KeptFruit[itemID] = count
+			}
+			changed = true
+		}
+	}
+	if changed {
+		mt.save()
+	}
+}
+
+// GetMutationStats 按作物汇总变异统计，按变异率降序
+func (mt *MutationTracker) GetMutationStats() []*MutationStats {
+	mt.mu.Lock()
+	mt.ensureLoaded()
+	states := []*mutationState{&mt.state}
+	if mt.gid == 0 {
+		// 未登录 (离线分析) 时汇总所有账号的变异数据
+		states = nil
+		paths, _ := filepath.Glob(filepath.Join(LedgerDir, "mutation-*.json"))
+		for _, path := range paths {
+			state, err := readMutationState(path)
+			if err != nil {
+				utils.LogWarn("变异", err.Error())
+				continue
+			}
+			states = append(states, state)
+		}
+	}
+	mt.mu.Unlock()
+
+	statsMap := make(map[int64]*MutationStats)
+	getStats := func(plantID int64) *MutationStats {
+		stats, ok := statsMap[plantID]
+		if !ok {
+			stats = &MutationStats{
+				PlantID:   plantID,
+				Name:      Config.GetPlantName(int(plantID)),
+				ByWeather: make(map[int64]int64),
+				ByMutant:  make(map[string]int64),
+			}
+			statsMap[plantID] = stats
+		}
+		return stats
+	}
+
+	for _, state := range states {
+		for plantID, observed := range state.Observed {
+			getStats(plantID).Observed += observed
+		}
+		for _, rec := range state.History {
+			stats := getStats(rec.PlantID)
+			stats.Mutations++
+			stats.ByWeather[rec.WeatherID]++
+			stats.ByMutant[rec.MutantName]++
+		}
+	}
+
+	result := make([]*MutationStats, 0, len(statsMap))
+	for _, stats := range statsMap {
+		result = append(result, stats)
+	}
+	rate := func(s *MutationStats) float64 {
+		if s.Observed == 0 {
+			return 0
+		}
+		return float64(s.Mutations) / float64(s.Observed)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if rate(result[i]) != rate(result[j]) {
+			return rate(result[i]) > rate(result[j])
+		}
+		return result[i].PlantID < result[j].PlantID
+	})
+	return result
+}
+
+// PrintMutationReport 打印各作物的变异率和天气分布
+func (mt *MutationTracker) PrintMutationReport() {
+	stats := mt.GetMutationStats()
+	hasMutation := false
+	for _, s := range stats {
+		if s.Mutations > 0 {
+			hasMutation = true
+			break
+		}
+	}
+	if !hasMutation {
+		fmt.Println("\n暂无变异记录")
+		return
+	}
+
+	fmt.Printf("\n========== 作物变异统计 ==========\n")
+	fmt.Printf("%-12s %-8s %-8s %-8s %s\n", "作物", "种植", "变异", "变异率", "天气分布 / 变异结果")
+	for _, s := range stats {
+		if s.Mutations == 0 {
+			continue
+		}
+		rate := 0.0
+		if s.Observed > 0 {
+			rate = float64(s.Mutations) / float64(s.Observed) * 100
+		}
+
+		var weathers []int64
+		for weatherID := range s.ByWeather {
+			weathers = append(weathers, weatherID)
+		}
+		sort.Slice(weathers, func(i, j int) bool { return weathers[i] < weathers[j] })
+		detail := ""
+		for _, weatherID := range weathers {
+			detail += fmt.Sprintf("天气%d×%d ", weatherID, s.ByWeather[weatherID])
+		}
+		for name, count := range s.ByMutant {
+			detail += fmt.Sprintf("%s×%d ", name, count)
+		}
+
+		fmt.Printf("%-12s %-8d %-8d %-7.1f%% %s\n", s.Name, s.Observed, s.Mutations, rate, detail)
+	}
+	fmt.Println("==================================")
+}
@@ -0,0 +1,306 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// defaultOpLimitIDs 操作对应的服务器限制ID初始值 (来自 plantpb.proto 的说明，未经验证)
+var defaultOpLimitIDs = map[int32]int64{
+	OpWaterLand:   10001,
+	OpInsecticide: 10002,
+	OpWeedOut:     10003,
+	OpSteal:       10004,
+	OpPutInsects:  10005,
+	OpPutWeeds:    10006,
+}
+
+// opLimitMapping 操作与服务器限制ID的对应关系 (持久化)
+type opLimitMapping struct {
+	Op       int32  `json:"op"`
+	Name     string `json:"name"`
+	LimitID  int64  `json:"limitId"`
+	Verified bool   `json:"verified"` // 是否已通过实际操作确认
+}
+
+// OperationLimitService 操作限制: 保存服务器下发的每日限制，并通过观察每次操作后哪个限制ID的次数变化学习操作对应的限制ID
+type OperationLimitService struct {
+	limits   map[int64]*plantpb.OperationLimit // 服务器限制ID -> 限制
+	mapping  map[int32]int64                   // 操作 -> 服务器限制ID
+	verified map[int32]bool
+	reserved map[int32]int64 // 已发出请求但还没有回复的次数 (并发巡查共用剩余次数)
+	loaded   bool
+	gid      int64 // 已加载对应关系的账号
+	mu       sync.RWMutex
+}
+
+var OpLimits *OperationLimitService
+
+func init() {
+	OpLimits = &OperationLimitService{
+		limits:   make(map[int64]*plantpb.OperationLimit),
+		mapping:  make(map[int32]int64),
+		verified: make(map[int32]bool),
+		reserved: make(map[int32]int64),
+	}
+	for op, id := range defaultOpLimitIDs {
+		OpLimits.mapping[op] = id
+	}
+}
+
+// opLimitsFilePath 账号的限制ID对应关系文件路径
+func opLimitsFilePath(gid int64) string {
+	return filepath.Join(StateDir, fmt.Sprintf("op_limit_ids-%d.json", gid))
+}
+
+// ensureLoaded 首次使用或切换账号时加载该账号已学习的对应关系 (调用方持有写锁)
+func (ols *OperationLimitService) ensureLoaded() {
+	gid := currentGID()
+	if ols.loaded && ols.gid == gid {
+		return
+	}
+	ols.loaded = true
+	ols.gid = gid
+	ols.mapping = make(map[int32]int64, len(defaultOpLimitIDs))
+	for op, id := range defaultOpLimitIDs {
+		ols.mapping[op] = id
+	}
+	ols.verified = make(map[int32]bool)
+	if gid == 0 {
+		return
+	}
+
+	data, err := os.ReadFile(opLimitsFilePath(gid))
+	if err != nil {
+		return
+	}
+	var list []opLimitMapping
+	if err := json.Unmarshal(data, &list); err != nil {
+		utils.LogWarn("操作限制", fmt.Sprintf("解析限制ID对应关系失败: %v", err))
+		return
+	}
+	for _, m := range list {
+		ols.mapping[m.Op] = m.LimitID
+		ols.verified[m.Op] = m.Verified
+	}
+}
+
+// save 保存对应关系 (调用方持有锁)
+func (ols *OperationLimitService) save() {
+	if ols.gid == 0 {
+		return
+	}
+	list := make([]opLimitMapping, 0, len(ols.mapping))
+	for op, id := range ols.mapping {
+		list = append(list, opLimitMapping{Op: op, Name: OpNames[op], LimitID: id, Verified: ols.verified[op]})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Op < list[j].Op })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(StateDir, 0755); err != nil {
+		return
+	}
+	if err := os.WriteFile(opLimitsFilePath(ols.gid), data, 0644); err != nil {
+		utils.LogWarn("操作限制", fmt.Sprintf("保存限制ID对应关系失败: %v", err))
+	}
+}
+
+// isStaleLimit 同一天内次数比已保存的少，说明是并发请求中较早的回复，不应覆盖
+func isStaleLimit(prev, limit *plantpb.OperationLimit) bool {
+	return prev != nil && (limit.DayTimes < prev.DayTimes || limit.DayExpTimes < prev.DayExpTimes)
+}
+
+// Update 保存服务器下发的限制 (不学习对应关系)
+func (ols *OperationLimitService) Update(limits []*plantpb.OperationLimit) {
+	ols.mu.Lock()
+	defer ols.mu.Unlock()
+	for _, limit := range limits {
+		if limit != nil && !isStaleLimit(ols.limits[limit.Id], limit) {
+			ols.limits[limit.Id] = limit
+		}
+	}
+}
+
+// Record 保存某次操作回复中的限制，只有一个限制ID的次数增加时把它记为该操作的限制ID
+// 其他操作有请求未回复时，增加的次数可能来自那些请求，只保存限制不学习
+func (ols *OperationLimitService) Record(op int32, limits []*plantpb.OperationLimit) {
+	ols.mu.Lock()
+	defer ols.mu.Unlock()
+	ols.ensureLoaded()
+
+	var changed []int64
+	for _, limit := range limits {
+		if limit == nil {
+			continue
+		}
+		prev, ok := ols.limits[limit.Id]
+		if isStaleLimit(prev, limit) {
+			continue
+		}
+		if ok && (limit.DayTimes > prev.DayTimes || limit.DayExpTimes > prev.DayExpTimes) {
+			changed = append(changed, limit.Id)
+		}
+		ols.limits[limit.Id] = limit
+	}
+	if len(changed) != 1 {
+		return // 没有变化或无法区分
+	}
+	for other, n := range ols.reserved {
+		if other != op && n > 0 {
+			return
+		}
+	}
+
+	id := changed[0]
+	if ols.mapping[op] == id && ols.verified[op] {
+		return
+	}
+	if old, ok := ols.mapping[op]; ok && old != id {
+		utils.Log("操作限制", fmt.Sprintf("操作 %s 的限制ID由 %d 更正为 %d", OpNames[op], old, id))
+	} else {
+		utils.Log("操作限制", fmt.Sprintf("已确认操作 %s 的限制ID为 %d", OpNames[op], id))
+	}
+	// 同一限制ID不能属于两个操作
+	for other, otherID := range ols.mapping {
+		if other != op && otherID == id {
+			delete(ols.mapping, other)
+			delete(ols.verified, other)
+		}
+	}
+	ols.mapping[op] = id
+	ols.verified[op] = true
+	ols.save()
+}
+
+// Limit 获取操作当前的限制 (没有数据时返回nil)
+func (ols *OperationLimitService) Limit(op int32) *plantpb.OperationLimit {
+	ols.mu.Lock()
+	defer ols.mu.Unlock()
+	ols.ensureLoaded()
+
+	id, ok := ols.mapping[op]
+	if !ok {
+		return nil
+	}
+	return ols.limits[id]
+}
+
+// remaining 剩余次数，扣除已预留的次数 (调用方持有锁)
+func (ols *OperationLimitService) remaining(op int32) int64 {
+	id, ok := ols.mapping[op]
+	if !ok {
+		return -1
+	}
+	limit := ols.limits[id]
+	if limit == nil || limit.DayTimesLt <= 0 {
+		return -1
+	}
+	return max(limit.DayTimesLt-limit.DayTimes-ols.reserved[op], 0)
+}
+
+// Remaining 操作今日剩余次数 (-1 为没有限制信息或无上限)
+func (ols *OperationLimitService) Remaining(op int32) int64 {
+	ols.mu.Lock()
+	defer ols.mu.Unlock()
+	ols.ensureLoaded()
+	return ols.remaining(op)
+}
+
+// Reserve 为即将发出的请求预留次数，返回实际预留的次数 (-1 为不限，无需释放)
+func (ols *OperationLimitService) Reserve(op int32, want int64) int64 {
+	ols.mu.Lock()
+	defer ols.mu.Unlock()
+	ols.ensureLoaded()
+
+	remaining := ols.remaining(op)
+	if remaining < 0 {
+		return -1
+	}
+	granted := min(want, remaining)
+	ols.reserved[op] += granted
+	return granted
+}
+
+// Release 收到回复 (已更新限制) 后释放预留的次数
+func (ols *OperationLimitService) Release(op int32, n int64) {
+	if n <= 0 {
+		return
+	}
+	ols.mu.Lock()
+	defer ols.mu.Unlock()
+	ols.reserved[op] = max(ols.reserved[op]-n, 0)
+}
+
+// RemainingExp 操作今日剩余可获得经验的次数 (-1 为没有限制信息或无上限)
+func (ols *OperationLimitService) RemainingExp(op int32) int64 {
+	limit := ols.Limit(op)
+	if limit == nil || limit.DayExTimesLt <= 0 {
+		return -1
+	}
+	return max(limit.DayExTimesLt-limit.DayExpTimes, 0)
+}
+
+// IsReached 操作是否达到今日上限
+func (ols *OperationLimitService) IsReached(op int32) bool {
+	return ols.Remaining(op) == 0
+}
+
+// Reset 每日重置时清空限制 (保留已学习的对应关系)
+func (ols *OperationLimitService) Reset() {
+	ols.mu.Lock()
+	defer ols.mu.Unlock()
+	ols.limits = make(map[int64]*plantpb.OperationLimit)
+}
+
+// List 当前所有限制 (用于状态快照)
+func (ols *OperationLimitService) List() []*plantpb.OperationLimit {
+	ols.mu.RLock()
+	defer ols.mu.RUnlock()
+	result := make([]*plantpb.OperationLimit, 0, len(ols.limits))
+	for _, limit := range ols.limits {
+		result = append(result, limit)
+	}
+	return result
+}
+
+// Summary 各操作的剩余次数和剩余经验次数
+func (ols *OperationLimitService) Summary() string {
+	ops := []int32{OpSteal, OpWaterLand, OpWeedOut, OpInsecticide}
+	var parts []string
+	for _, op := range ops {
+		remaining, remainingExp := ols.Remaining(op), ols.RemainingExp(op)
+		if remaining < 0 && remainingExp < 0 {
+			continue
+		}
+		text := fmt.Sprintf("%s 剩%s次", OpNames[op], formatRemaining(remaining))
+		if remainingExp >= 0 {
+			text += fmt.Sprintf("(经验%d次)", remainingExp)
+		}
+		ols.mu.RLock()
+		if !ols.verified[op] {
+			text += "?"
+		}
+		ols.mu.RUnlock()
+		parts = append(parts, text)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// formatRemaining 剩余次数文本 (-1 显示为不限)
+func formatRemaining(n int64) string {
+	if n < 0 {
+		return "不限"
+	}
+	return fmt.Sprintf("%d", n)
+}
@@ -0,0 +1,410 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/tools"
+)
+
+// 规划最多模拟的种植轮数 (防止目标不可达时无限循环)
+const PlanMaxSteps = 5000
+
+// 每秒可种植的地块数 (与 tools 经验分析一致)
+const PlanPlantsPerSec = tools.NoFertPlantSpeedPerSec
+
+// PlanSeed 规划用的种子数据
+type PlanSeed struct {
+	SeedID        int64
+	PlantID       int64
+	Name          string
+	RequiredLevel int
+	GrowSec       int64
+	Exp           int64 // 每块地每轮经验
+}
+
+// PlanStep 规划中的一轮种植
+type PlanStep struct {
+	Start      time.Time
+	HarvestAt  time.Time // 实际可以收获的时间 (成熟时离线则推迟到上线)
+	Seed       *PlanSeed
+	Exp        int64 // 本轮获得经验
+	TotalExp   int64 // 本轮结束后的总经验
+	LevelAfter int
+}
+
+// LevelPlan 等级目标规划结果
+type LevelPlan struct {
+	TargetLevel int
+	Deadline    time.Time
+	Steps       []*PlanStep
+	Reached     bool      // 是否能达到目标等级
+	ReachedAt   time.Time // 达到目标等级的时间
+}
+
+// OnTime 是否能在截止时间前达到目标
+func (lp *LevelPlan) OnTime() bool {
+	return lp.Reached && (lp.Deadline.IsZero() || !lp.ReachedAt.After(lp.Deadline))
+}
+
+// LevelPlanner 等级目标规划器
+type LevelPlanner struct {
+	TargetLevel int
+	Deadline    time.Time
+	seeds       []*PlanSeed
+	seedLands   int // seeds 按该地块数计算
+
+	// 当前应种种子的缓存 (等级/地块数不变且本轮未收获时不重新规划)
+	cachedLevel  int
+	cachedLands  int
+	cachedSeedID int64
+	cachedUntil  time.Time
+	mu           sync.Mutex
+}
+
+var Planner *LevelPlanner
+
+func init() {
+	Planner = &LevelPlanner{}
+}
+
+// Enabled 是否设置了等级目标
+func (lp *LevelPlanner) Enabled() bool {
+	return lp.TargetLevel > 0
+}
+
+// SetGoal 设置等级目标和截止时间
+func (lp *LevelPlanner) SetGoal(targetLevel int, deadline time.Time) {
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	lp.TargetLevel = targetLevel
+	lp.Deadline = deadline
+	lp.cachedSeedID = 0
+}
+
+// loadPlanSeeds 加载种子数据: 优先使用经验分析数据，缺失时读取种子商店导出数据
+func loadPlanSeeds(lands int) []*PlanSeed {
+	var seeds []*PlanSeed
+	for _, info := range tools.CalculateSeedExp(lands) {
+		seeds = append(seeds, &PlanSeed{
+			SeedID:        info.SeedID,
+			PlantID:       info.PlantID,
+			Name:          info.Name,
+			RequiredLevel: info.RequiredLevel,
+			GrowSec:       info.GrowTimeSec,
+			Exp:           info.ExpPerCycle,
+		})
+	}
+	if len(seeds) > 0 {
+		return seeds
+	}
+
+	data, err := os.ReadFile(filepath.Join("data", "seed-shop-merged-export.json"))
+	if err != nil {
+		return nil
+	}
+	var seedShopData struct {
+		Rows []struct {
+			SeedID        int64  `json:"seedId"`
+			PlantID       int64  `json:"plantId"`
+			Name          string `json:"name"`
+			RequiredLevel int    `json:"requiredLevel"`
+			Exp           int64  `json:"exp"`
+			ExpPerCycle   int64  `json:"expPerCycle"`
+			GrowTimeSec   int64  `json:"growTimeSec"`
+		} `json:"rows"`
+	}
+	if err := json.Unmarshal(data, &seedShopData); err != nil {
+		return nil
+	}
+	for _, row := range seedShopData.Rows {
+		if row.SeedID <= 0 || row.GrowTimeSec <= 0 {
+			continue
+		}
+		exp := row.ExpPerCycle
+		if exp <= 0 {
+			exp = row.Exp
+		}
+		seeds = append(seeds, &PlanSeed{
+			SeedID:        row.SeedID,
+			PlantID:       row.PlantID,
+			Name:          row.Name,
+			RequiredLevel: row.RequiredLevel,
+			GrowSec:       row.GrowTimeSec,
+			Exp:           exp,
+		})
+	}
+	return seeds
+}
+
+// getSeeds 获取规划用种子 (首次使用或地块数变化时加载)
+func (lp *LevelPlanner) getSeeds(lands int) []*PlanSeed {
+	if lp.seeds == nil || lp.seedLands != lands {
+		lp.seeds = loadPlanSeeds(lands)
+		lp.seedLands = lands
+	}
+	return lp.seeds
+}
+
+// levelForExp 根据总经验计算等级
+func levelForExp(totalExp int64) int {
+	level := 1
+	for lv, exp := range Config.GetLevelExpTable() {
+		if totalExp >= exp && lv > level {
+			level = lv
+		}
+	}
+	return level
+}
+
+// onlineWindow 每天的在线时间段 (分钟, end 可小于 start 表示跨零点)
+type onlineWindow struct {
+	start int
+	end   int
+}
+
+// parseClock 解析 "HH:MM"
+func parseClock(s string) (int, error) {
+	parts := strings.Split(strings.TrimSpace(s), ":")
+	if len(parts) != 2 {
+		return 0, fmt.Errorf("时间格式错误: %s", s)
+	}
+	h, err1 := strconv.Atoi(parts[0])
+	m, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || h < 0 || h > 24 || m < 0 || m > 59 {
+		return 0, fmt.Errorf("时间格式错误: %s", s)
+	}
+	return h*60 + m, nil
+}
+
+// ParseOnlineWindows 解析在线时间段，如 "08:00-12:00" "22:00-02:00"
+func ParseOnlineWindows(specs []string) ([]onlineWindow, error) {
+	var windows []onlineWindow
+	for _, spec := range specs {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		parts := strings.Split(spec, "-")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("在线时间段格式错误: %s", spec)
+		}
+		start, err := parseClock(parts[0])
+		if err != nil {
+			return nil, err
+		}
+		end, err := parseClock(parts[1])
+		if err != nil {
+			return nil, err
+		}
+		windows = append(windows, onlineWindow{start: start, end: end})
+	}
+	return windows, nil
+}
+
+// getOnlineWindows 当前配置的在线时间段 (配置错误时视为全天在线)
+func getOnlineWindows() []onlineWindow {
+	windows, err := ParseOnlineWindows(config.Current.OnlineWindows)
+	if err != nil {
+		return nil
+	}
+	return windows
+}
+
+// HasOfflineWindows 是否配置了在线时间段 (存在离线时间)
+func HasOfflineWindows() bool {
+	return len(getOnlineWindows()) > 0
+}
+
+// IsOnlineAt 该时刻是否在线 (未配置在线时间段时全天在线)
+func IsOnlineAt(t time.Time) bool {
+	windows := getOnlineWindows()
+	if len(windows) == 0 {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	for _, w := range windows {
+		if w.start <= w.end {
+			if minute >= w.start && minute < w.end {
+				return true
+			}
+		} else if minute >= w.start || minute < w.end {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOnlineAt 该时刻之后最近的在线时间 (在线时返回自身)
+func NextOnlineAt(t time.Time) time.Time {
+	if IsOnlineAt(t) {
+		return t
+	}
+	windows := getOnlineWindows()
+	dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	best := time.Time{}
+	for day := 0; day <= 1; day++ {
+		for _, w := range windows {
+			start := dayStart.AddDate(0, 0, day).Add(time.Duration(w.start) * time.Minute)
+			if start.After(t) && (best.IsZero() || start.Before(best)) {
+				best = start
+			}
+		}
+	}
+	if best.IsZero() {
+		return t
+	}
+	return best
+}
+
+// Plan 从当前等级/经验出发，模拟按在线时间段种植，生成达到目标等级的种植计划
+func (lp *LevelPlanner) Plan(level int, totalExp int64, lands int, now time.Time) *LevelPlan {
+	plan := &LevelPlan{TargetLevel: lp.TargetLevel, Deadline: lp.Deadline}
+	targetExp, ok := Config.GetLevelExpTable()[lp.TargetLevel]
+	if !ok || lands <= 0 {
+		return plan
+	}
+	if totalExp >= targetExp {
+		plan.Reached = true
+		plan.ReachedAt = now
+		return plan
+	}
+
+	seeds := lp.getSeeds(lands)
+	plantSec := float64(lands) / PlanPlantsPerSec
+	t := now
+	for step := 0; step < PlanMaxSteps && totalExp < targetExp; step++ {
+		t = NextOnlineAt(t)
+		best, bestHarvest := lp.chooseSeed(seeds, level, targetExp-totalExp, lands, plantSec, t)
+		if best == nil {
+			break
+		}
+
+		gained := best.Exp * int64(lands)
+		totalExp += gained
+		level = levelForExp(totalExp)
+		plan.Steps = append(plan.Steps, &PlanStep{
+			Start:      t,
+			HarvestAt:  bestHarvest,
+			Seed:       best,
+			Exp:        gained,
+			TotalExp:   totalExp,
+			LevelAfter: level,
+		})
+		t = bestHarvest
+	}
+
+	if totalExp >= targetExp && len(plan.Steps) > 0 {
+		plan.Reached = true
+		plan.ReachedAt = plan.Steps[len(plan.Steps)-1].HarvestAt
+	}
+	return plan
+}
+
+// chooseSeed 选择本轮要种的种子:
+//   - 本轮就能达到目标时，选收获最早的种子
+//   - 设置了截止时间时，只在截止前能收获的种子中选择 (截止后才成熟的经验不算数)，都来不及时不受此限制
+//   - 其余按 (本轮经验 / 本轮实际占用时间) 最高选择: 成熟时离线需要等到上线才能收获，所以离线前长周期作物更划算
+func (lp *LevelPlanner) chooseSeed(seeds []*PlanSeed, level int, remaining int64, lands int, plantSec float64, t time.Time) (*PlanSeed, time.Time) {
+	var best, finish, beforeDeadline *PlanSeed
+	var bestHarvest, finishHarvest, deadlineHarvest time.Time
+	bestRate, deadlineRate := 0.0, 0.0
+	for _, seed := range seeds {
+		if seed.RequiredLevel > level || seed.Exp <= 0 {
+			continue
+		}
+		mature := t.Add(time.Duration(float64(seed.GrowSec)+plantSec) * time.Second)
+		harvestAt := NextOnlineAt(mature)
+		rate := float64(seed.Exp) / harvestAt.Sub(t).Seconds()
+		if best == nil || rate > bestRate {
+			best, bestHarvest, bestRate = seed, harvestAt, rate
+		}
+		if seed.Exp*int64(lands) >= remaining && (finish == nil || harvestAt.Before(finishHarvest)) {
+			finish, finishHarvest = seed, harvestAt
+		}
+		if !lp.Deadline.IsZero() && !harvestAt.After(lp.Deadline) && (beforeDeadline == nil || rate > deadlineRate) {
+			beforeDeadline, deadlineHarvest, deadlineRate = seed, harvestAt, rate
+		}
+	}
+	switch {
+	case finish != nil:
+		return finish, finishHarvest
+	case beforeDeadline != nil:
+		return beforeDeadline, deadlineHarvest
+	}
+	return best, bestHarvest
+}
+
+// PlannedSeedID 返回现在应该种的种子 (未设置目标时返回0)；
+// 等级和地块数不变时复用上次的规划结果，直到规划的这一轮收获
+func (lp *LevelPlanner) PlannedSeedID(level int, totalExp int64, lands int) int64 {
+	if !lp.Enabled() {
+		return 0
+	}
+	lp.mu.Lock()
+	defer lp.mu.Unlock()
+	now := time.Now()
+	if lp.cachedSeedID > 0 && lp.cachedLevel == level && lp.cachedLands == lands && now.Before(lp.cachedUntil) {
+		return lp.cachedSeedID
+	}
+
+	plan := lp.Plan(level, totalExp, lands, now)
+	if len(plan.Steps) == 0 {
+		lp.cachedSeedID = 0
+		return 0
+	}
+	first := plan.Steps[0]
+	lp.cachedLevel, lp.cachedLands = level, lands
+	lp.cachedSeedID, lp.cachedUntil = first.Seed.SeedID, first.HarvestAt
+	return lp.cachedSeedID
+}
+
+// PrintPlan 打印种植计划和预计等级曲线 (相同种子的连续轮次合并显示)
+func (lp *LevelPlanner) PrintPlan(plan *LevelPlan) {
+	fmt.Printf("\n========== 等级目标: Lv%d ==========\n", plan.TargetLevel)
+	if len(plan.Steps) == 0 {
+		if plan.Reached {
+			fmt.Println("已达到目标等级")
+		} else {
+			fmt.Println("无法生成种植计划 (缺少等级或种子数据)")
+		}
+		return
+	}
+
+	fmt.Printf("%-17s %-17s %-10s %-6s %-10s %-6s\n", "开始", "收获", "作物", "轮数", "总经验", "等级")
+	const timeFmt = "01-02 15:04"
+	for i := 0; i < len(plan.Steps); {
+		first := plan.Steps[i]
+		j := i
+		for j+1 < len(plan.Steps) && plan.Steps[j+1].Seed == first.Seed &&
+			plan.Steps[j+1].Start.Equal(plan.Steps[j].HarvestAt) {
+			j++
+		}
+		last := plan.Steps[j]
+		fmt.Printf("%-17s %-17s %-10s %-6d %-10d Lv%d\n",
+			first.Start.Format(timeFmt), last.HarvestAt.Format(timeFmt), first.Seed.Name, j-i+1, last.TotalExp, last.LevelAfter)
+		i = j + 1
+	}
+
+	switch {
+	case !plan.Reached:
+		fmt.Printf("在 %d 轮内无法达到 Lv%d\n", PlanMaxSteps, plan.TargetLevel)
+	case plan.Deadline.IsZero():
+		fmt.Printf("预计 %s 达到 Lv%d\n", plan.ReachedAt.Format("2006-01-02 15:04"), plan.TargetLevel)
+	case plan.OnTime():
+		fmt.Printf("预计 %s 达到 Lv%d, 早于截止时间 %s\n",
+			plan.ReachedAt.Format("2006-01-02 15:04"), plan.TargetLevel, plan.Deadline.Format("2006-01-02 15:04"))
+	default:
+		fmt.Printf("预计 %s 达到 Lv%d, 晚于截止时间 %s (%s)\n",
+			plan.ReachedAt.Format("2006-01-02 15:04"), plan.TargetLevel, plan.Deadline.Format("2006-01-02 15:04"),
+			FormatGrowTime(int(plan.ReachedAt.Sub(plan.Deadline).Seconds())))
+	}
+	fmt.Println("====================================")
+}
@@ -0,0 +1,39 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+)
+
+// RequestLimiter 全局请求限速: 所有游戏请求 (包括并发的好友巡查) 之间至少间隔 RequestMinInterval
+type RequestLimiter struct {
+	next time.Time // 下一个请求最早的发送时间
+	mu   sync.Mutex
+}
+
+var Limiter *RequestLimiter
+
+func init() {
+	Limiter = &RequestLimiter{}
+}
+
+// Wait 等待到可以发送下一个请求
+func (rl *RequestLimiter) Wait() {
+	interval := config.Current.RequestMinInterval
+	if interval <= 0 {
+		return
+	}
+
+	rl.mu.Lock()
+	now := time.Now()
+	at := rl.next
+	if at.Before(now) {
+		at = now
+	}
+	rl.next = at.Add(interval)
+	rl.mu.Unlock()
+
+	time.Sleep(time.Until(at))
+}
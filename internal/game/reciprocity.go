@@ -0,0 +1,413 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/friendpb"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// 互惠分权重
+const (
+	ReciprocityTheftWeight = 2 // 偷我们一次
+	ReciprocityBadWeight   = 3 // 给我们放一次草/虫
+	ReciprocityHelpWeight  = 2 // 帮我们除草/除虫/浇水一次
+)
+
+// 互惠分只统计该时间内的行为 (旧的偷菜/使坏/帮忙逐渐失效)
+const ReciprocityWindow = 7 * 24 * time.Hour
+
+// 土地的草/虫/干旱消失前该时间内来过的好友视为帮忙的人
+const ReciprocityHelperWindow = 2 * time.Minute
+
+// 自家巡查除草/除虫/浇水后该时间内土地状态的消失不算好友帮忙
+const ReciprocityOwnCareWindow = 1 * time.Minute
+
+// 互惠行为类型
+const (
+	ReciprocitySteal  = "steal"
+	ReciprocityWeed   = "weed"
+	ReciprocityInsect = "insect"
+	ReciprocityHelp   = "help"
+)
+
+// ReciprocityEvent 好友对我们的一次行为
+type ReciprocityEvent struct {
+	Time int64  `json:"time"`
+	Kind string `json:"kind"`
+}
+
+// FriendReciprocity 单个好友的互惠记录
+type FriendReciprocity struct {
+	GID        int64              `json:"gid"`
+	Name       string             `json:"name"`
+	Stole      int64              `json:"stole"`      // 偷我们的次数
+	StoleFruit int64              `json:"stoleFruit"` // 偷走的果实数
+	Weeds      int64              `json:"weeds"`      // 给我们放草的次数
+	Insects    int64              `json:"insects"`    // 给我们放虫的次数
+	Helped     int64              `json:"helped"`     // 帮我们除草/除虫/浇水的次数
+	HelpGiven  int64              `json:"helpGiven"`  // 我们帮他的次数
+	StealTaken int64              `json:"stealTaken"` // 我们偷他的次数
+	Recent     []ReciprocityEvent `json:"recent"`     // 窗口内的行为 (计算互惠分)
+}
+
+// recentCounts 窗口内的偷菜、使坏 (放草/放虫)、帮忙次数
+func (fr *FriendReciprocity) recentCounts(now int64) (steals, bad, helps int64) {
+	since := now - int64(ReciprocityWindow/time.Second)
+	for _, ev := range fr.Recent {
+		if ev.Time < since {
+			continue
+		}
+		switch ev.Kind {
+		case ReciprocitySteal:
+			steals++
+		case ReciprocityWeed, ReciprocityInsect:
+			bad++
+		case ReciprocityHelp:
+			helps++
+		}
+	}
+	return
+}
+
+// Score 互惠分: 窗口内帮忙加分，偷菜和放草放虫扣分
+func (fr *FriendReciprocity) Score() int64 {
+	steals, bad, helps := fr.recentCounts(utils.GetServerTimeSec())
+	return helps*ReciprocityHelpWeight - steals*ReciprocityTheftWeight - bad*ReciprocityBadWeight
+}
+
+// addEvent 记录一次行为并丢弃窗口外的旧行为
+func (fr *FriendReciprocity) addEvent(kind string, now int64) {
+	since := now - int64(ReciprocityWindow/time.Second)
+	kept := fr.Recent[:0]
+	for _, ev := range fr.Recent {
+		if ev.Time >= since {
+			kept = append(kept, ev)
+		}
+	}
+	fr.Recent = append(kept, ReciprocityEvent{Time: now, Kind: kind})
+}
+
+// reciprocityLand 自家一块地上的放草/放虫人和干旱状态 (用于判断新增和被别人清除)
+type reciprocityLand struct {
+	PlantKey string  `json:"plantKey"`
+	Weeds    []int64 `json:"weeds"`
+	Insects  []int64 `json:"insects"`
+	Dry      bool    `json:"dry"`
+}
+
+// reciprocityState 互惠数据 (持久化)
+type reciprocityState struct {
+	Friends map[int64]*FriendReciprocity `json:"friends"`
+	Lands   map[int64]*reciprocityLand   `json:"lands"`
+}
+
+// ReciprocityTracker 好友互惠: 记录谁帮过我们、谁偷过、谁放过草虫，用于安排巡查顺序和操作
+type ReciprocityTracker struct {
+	state       reciprocityState
+	loaded      bool
+	gid         int64           // 已加载数据的账号
+	ownCare     map[int64]int64 // 土地ID -> 自家最近一次除草/除虫/浇水的时间
+	lastVisitor int64           // 最近来过我们农场的好友 (偷菜者)
+	lastVisitAt int64
+	mu          sync.Mutex
+}
+
+var Reciprocity *ReciprocityTracker
+
+func init() {
+	Reciprocity = &ReciprocityTracker{
+		state: reciprocityState{
+			Friends: make(map[int64]*FriendReciprocity),
+			Lands:   make(map[int64]*reciprocityLand),
+		},
+		ownCare: make(map[int64]int64),
+	}
+}
+
+// reciprocityFilePath 账号的互惠数据文件路径
+func reciprocityFilePath(gid int64) string {
+	return filepath.Join(LedgerDir, fmt.Sprintf("reciprocity-%d.json", gid))
+}
+
+// readReciprocityState 读取互惠数据文件
+func readReciprocityState(path string) (*reciprocityState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var state reciprocityState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("解析互惠数据失败: %v", err)
+	}
+	if state.Friends == nil {
+		state.Friends = make(map[int64]*FriendReciprocity)
+	}
+	if state.Lands == nil {
+		state.Lands = make(map[int64]*reciprocityLand)
+	}
+	return &state, nil
+}
+
+// ensureLoaded 首次使用或切换账号时加载该账号的历史数据 (调用方持有锁)
+func (rt *ReciprocityTracker) ensureLoaded() {
+	gid := currentGID()
+	if rt.loaded && rt.gid == gid {
+		return
+	}
+	rt.loaded = true
+	rt.gid = gid
+	rt.state = reciprocityState{
+		Friends: make(map[int64]*FriendReciprocity),
+		Lands:   make(map[int64]*reciprocityLand),
+	}
+	rt.ownCare = make(map[int64]int64)
+	rt.lastVisitor, rt.lastVisitAt = 0, 0
+	if gid == 0 {
+		return
+	}
+
+	state, err := readReciprocityState(reciprocityFilePath(gid))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogWarn("互惠", err.Error())
+		}
+		return
+	}
+	rt.state = *state
+}
+
+// save 保存互惠数据 (调用方持有锁)
+func (rt *ReciprocityTracker) save() {
+	if IsDryRun() || rt.gid == 0 {
+		return
+	}
+	data, err := json.MarshalIndent(rt.state, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(LedgerDir, 0755); err != nil {
+		return
+	}
+	if err := os.WriteFile(reciprocityFilePath(rt.gid), data, 0644); err != nil {
+		utils.LogWarn("互惠", fmt.Sprintf("保存互惠数据失败: %v", err))
+	}
+}
+
+// friend 获取好友记录，不存在时创建 (调用方持有锁)
+func (rt *ReciprocityTracker) friend(gid int64) *FriendReciprocity {
+	fr, ok := rt.state.Friends[gid]
+	if !ok {
+		fr = &FriendReciprocity{GID: gid}
+		rt.state.Friends[gid] = fr
+	}
+	if name := Friend.GetFriendName(gid); fr.Name == "" || name != fmt.Sprintf("GID:%d", gid) {
+		fr.Name = name
+	}
+	return fr
+}
+
+// diffGIDs 返回 b 中有而 a 中没有的GID
+func diffGIDs(a, b []int64) []int64 {
+	var result []int64
+	for _, gid := range b {
+		if !containsInt64(a, gid) {
+			result = append(result, gid)
+		}
+	}
+	return result
+}
+
+// ObserveLands 对比自家土地的放草/放虫人和干旱状态，记录新增的使坏和别人的帮忙
+// 服务器不告诉我们是谁除的草/虫、浇的水: 草/虫/干旱被清除时，归给刚来过我们农场的好友 (最近的偷菜者)
+func (rt *ReciprocityTracker) ObserveLands(lands []*plantpb.LandInfo) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.ensureLoaded()
+
+	now := utils.GetServerTimeSec()
+	changed := false
+	for _, land := range lands {
+		if land == nil || land.Plant == nil {
+			continue
+		}
+		plant := land.Plant
+		key := plantKeyOf(plant)
+		dry := plant.DryNum > 0
+
+		prev, ok := rt.state.Lands[land.Id]
+		if !ok {
+			// 首次见到该土地: 已有的放草/放虫人作为基线，不计入
+			prev = &reciprocityLand{PlantKey: key, Weeds: plant.WeedOwners, Insects: plant.InsectOwners, Dry: dry}
+			changed = true
+		} else if prev.PlantKey != key {
+			prev = &reciprocityLand{PlantKey: key}
+		}
+
+		for _, gid := range diffGIDs(prev.Weeds, plant.WeedOwners) {
+			fr := rt.friend(gid)
+			fr.Weeds++
+			fr.addEvent(ReciprocityWeed, now)
+			utils.Log("互惠", fmt.Sprintf("%s 在土地#%d 放了草", fr.Name, land.Id))
+			changed = true
+		}
+		for _, gid := range diffGIDs(prev.Insects, plant.InsectOwners) {
+			fr := rt.friend(gid)
+			fr.Insects++
+			fr.addEvent(ReciprocityInsect, now)
+			utils.Log("互惠", fmt.Sprintf("%s 在土地#%d 放了虫", fr.Name, land.Id))
+			changed = true
+		}
+
+		cleared := (len(prev.Weeds) > 0 && len(plant.WeedOwners) == 0) ||
+			(len(prev.Insects) > 0 && len(plant.InsectOwners) == 0) ||
+			(prev.Dry && !dry)
+		if cleared && rt.recordHelp(land.Id, now) {
+			changed = true
+		}
+
+		rt.state.Lands[land.Id] = &reciprocityLand{
+			PlantKey: key,
+			Weeds:    append([]int64(nil), plant.WeedOwners...),
+			Insects:  append([]int64(nil), plant.InsectOwners...),
+			Dry:      dry,
+		}
+	}
+	if changed {
+		rt.save()
+	}
+}
+
+// recordHelp 土地的草/虫/干旱被清除: 不是自家巡查做的，且刚有好友来过时记为该好友帮忙 (调用方持有锁)
+func (rt *ReciprocityTracker) recordHelp(landID int64, now int64) bool {
+	if at, ok := rt.ownCare[landID]; ok && now-at <= int64(ReciprocityOwnCareWindow/time.Second) {
+		return false
+	}
+	if rt.lastVisitor == 0 || now-rt.lastVisitAt > int64(ReciprocityHelperWindow/time.Second) {
+		return false
+	}
+	fr := rt.friend(rt.lastVisitor)
+	fr.Helped++
+	fr.addEvent(ReciprocityHelp, now)
+	utils.Log("互惠", fmt.Sprintf("%s 帮忙照料了土地#%d", fr.Name, landID))
+	return true
+}
+
+// MarkOwnCare 自家巡查即将除草/除虫/浇水的土地 (之后状态消失不算好友帮忙)
+func (rt *ReciprocityTracker) MarkOwnCare(landIds []int64) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	now := utils.GetServerTimeSec()
+	for _, landID := range landIds {
+		rt.ownCare[landID] = now
+	}
+}
+
+// RecordTheft 记录好友偷我们的作物 (同时记为最近来过的好友)
+func (rt *ReciprocityTracker) RecordTheft(gid int64, lost int64) {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.ensureLoaded()
+	now := utils.GetServerTimeSec()
+	fr := rt.friend(gid)
+	fr.Stole++
+	fr.StoleFruit += lost
+	fr.addEvent(ReciprocitySteal, now)
+	rt.lastVisitor, rt.lastVisitAt = gid, now
+	rt.save()
+}
+
+// RecordVisit 记录我们在好友农场的帮忙和偷菜次数
+func (rt *ReciprocityTracker) RecordVisit(gid int64, helped, stolen int64) {
+	if helped == 0 && stolen == 0 {
+		return
+	}
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.ensureLoaded()
+	fr := rt.friend(gid)
+	fr.HelpGiven += helped
+	fr.StealTaken += stolen
+	rt.save()
+}
+
+// Get 获取好友的互惠记录
+func (rt *ReciprocityTracker) Get(gid int64) FriendReciprocity {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	rt.ensureLoaded()
+	if fr, ok := rt.state.Friends[gid]; ok {
+		return *fr
+	}
+	return FriendReciprocity{GID: gid}
+}
+
+// Score 好友的互惠分
+func (rt *ReciprocityTracker) Score(gid int64) int64 {
+	fr := rt.Get(gid)
+	return fr.Score()
+}
+
+// OnlyTakes 好友最近是否只会偷菜/使坏 (不值得花有限的帮忙次数): 窗口内偷我们达到阈值或放过草虫,
+// 且帮我们的分数抵不过扣分；阈值为 0 时总是帮忙
+func (rt *ReciprocityTracker) OnlyTakes(gid int64) bool {
+	if config.Current.NoHelpTheftThreshold <= 0 {
+		return false
+	}
+	fr := rt.Get(gid)
+	steals, bad, helps := fr.recentCounts(utils.GetServerTimeSec())
+	if steals < int64(config.Current.NoHelpTheftThreshold) && bad == 0 {
+		return false
+	}
+	return helps*ReciprocityHelpWeight < steals*ReciprocityTheftWeight+bad*ReciprocityBadWeight
+}
+
+// VisitPriority 巡查顺序中的互惠部分: 有可偷作物的负分好友为小偷 (最先拜访, 分数越低越先),
+// 其余好友分数越高越先 (先帮互惠的好友)
+func (rt *ReciprocityTracker) VisitPriority(friend *friendpb.GameFriend) (thief bool, score int64) {
+	score = rt.Score(friend.GetGid())
+	return friend.GetPlant().GetStealPlantNum() > 0 && score < 0, score
+}
+
+// PrintReciprocityReport 输出互惠分排行 (未登录时汇总所有账号的数据)
+func (rt *ReciprocityTracker) PrintReciprocityReport() {
+	var list []FriendReciprocity
+	if gid := currentGID(); gid != 0 {
+		rt.mu.Lock()
+		rt.ensureLoaded()
+		for _, fr := range rt.state.Friends {
+			list = append(list, *fr)
+		}
+		rt.mu.Unlock()
+	} else {
+		paths, _ := filepath.Glob(filepath.Join(LedgerDir, "reciprocity-*.json"))
+		for _, path := range paths {
+			state, err := readReciprocityState(path)
+			if err != nil {
+				continue
+			}
+			for _, fr := range state.Friends {
+				list = append(list, *fr)
+			}
+		}
+	}
+
+	if len(list) == 0 {
+		return
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Score() > list[j].Score() })
+
+	utils.Log("互惠", fmt.Sprintf("好友互惠分 (%d 位, 最近 %d 天):", len(list), int(ReciprocityWindow/(24*time.Hour))))
+	for _, fr := range list {
+		utils.Log("互惠", fmt.Sprintf("  %s (GID:%d) 分数 %d: 偷我们 %d 次/%d 个, 放草 %d, 放虫 %d, 帮我们 %d | 我们帮 %d, 我们偷 %d",
+			fr.Name, fr.GID, fr.Score(), fr.Stole, fr.StoleFruit, fr.Weeds, fr.Insects, fr.Helped, fr.HelpGiven, fr.StealTaken))
+	}
+}
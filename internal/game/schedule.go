@@ -0,0 +1,386 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gofarm/internal/config"
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+)
+
+// 作息模块
+const (
+	ModuleFarm      = "farm"
+	ModuleFriend    = "friend"
+	ModuleTask      = "task"
+	ModuleWarehouse = "warehouse"
+	ModuleAll       = "*"
+)
+
+// 运行模式
+const (
+	ModeFull        = "full"         // 正常运行
+	ModeHarvestOnly = "harvest-only" // 只收获: 农场只收获和防偷, 不照料/购买/种植, 好友只偷菜不帮忙 (任务/仓库不支持)
+	ModePaused      = "paused"       // 暂停
+)
+
+// 状态栏中模块的显示顺序
+var scheduleModules = []string{ModuleFarm, ModuleFriend, ModuleTask, ModuleWarehouse}
+
+// 作息状态检查间隔 (规则按分钟切换)
+const ScheduleStatusInterval = 30 * time.Second
+
+// ActivitySchedule 作息时间表: 各模块按时间段切换运行模式
+type ActivitySchedule struct {
+	lastMode    map[string]string // 模块 -> 上次的模式 (用于记录切换日志)
+	lastStatus  string            // 上次推送到状态栏的作息
+	loopRunning bool
+	mu          sync.Mutex
+}
+
+var Schedule *ActivitySchedule
+
+func init() {
+	Schedule = &ActivitySchedule{
+		lastMode: make(map[string]string),
+	}
+}
+
+// scheduleModeEffect 模式对模块的影响 (用于切换日志)
+func scheduleModeEffect(module, mode string) string {
+	switch {
+	case mode == ModePaused:
+		return "停止巡查"
+	case mode == ModeHarvestOnly && module == ModuleFarm:
+		return "只收获, 不照料/购买/种植"
+	case mode == ModeHarvestOnly && module == ModuleFriend:
+		return "只偷菜, 不帮忙"
+	}
+	return ""
+}
+
+// scheduleModuleName 模块名称
+func scheduleModuleName(module string) string {
+	switch module {
+	case ModuleFarm:
+		return "农场"
+	case ModuleFriend:
+		return "好友"
+	case ModuleTask:
+		return "任务"
+	case ModuleWarehouse:
+		return "仓库"
+	case ModuleAll:
+		return "全部"
+	default:
+		return module
+	}
+}
+
+// scheduleModeName 模式名称
+func scheduleModeName(mode string) string {
+	switch mode {
+	case ModeFull:
+		return "正常"
+	case ModeHarvestOnly:
+		return "仅收获"
+	case ModePaused:
+		return "暂停"
+	default:
+		return mode
+	}
+}
+
+// ParseScheduleRules 解析作息规则，多条规则用分号分隔
+// 格式: 模块=模式[xN]@HH:MM-HH:MM[@星期]，星期如 1-5 或 6,7 (1=周一 ... 7=周日)
+// 例如: friend=paused@01:00-07:00;farm=harvest-only@09:00-18:00@1-5;*=fullx3@00:00-07:00
+func ParseScheduleRules(spec string) ([]config.ScheduleRule, error) {
+	var rules []config.ScheduleRule
+	for _, entry := range strings.Split(spec, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, "@")
+		if len(parts) < 2 || len(parts) > 3 {
+			return nil, fmt.Errorf("作息规则格式错误: %s", entry)
+		}
+
+		moduleMode := strings.SplitN(parts[0], "=", 2)
+		if len(moduleMode) != 2 {
+			return nil, fmt.Errorf("作息规则缺少模式: %s", entry)
+		}
+		rule := config.ScheduleRule{
+			Module: strings.TrimSpace(moduleMode[0]),
+			Mode:   strings.TrimSpace(moduleMode[1]),
+		}
+		if i := strings.LastIndex(rule.Mode, "x"); i > 0 {
+			scale, err := strconv.ParseFloat(rule.Mode[i+1:], 64)
+			if err != nil || scale <= 0 {
+				return nil, fmt.Errorf("作息规则间隔倍数错误: %s", entry)
+			}
+			rule.Mode, rule.IntervalScale = rule.Mode[:i], scale
+		}
+
+		window := strings.Split(parts[1], "-")
+		if len(window) != 2 {
+			return nil, fmt.Errorf("作息时间段格式错误: %s", entry)
+		}
+		rule.Start, rule.End = strings.TrimSpace(window[0]), strings.TrimSpace(window[1])
+
+		if len(parts) == 3 {
+			days, err := parseScheduleDays(parts[2])
+			if err != nil {
+				return nil, err
+			}
+			rule.Days = days
+		}
+
+		if err := ValidateScheduleRule(rule); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parseScheduleDays 解析星期列表 "1-5" / "6,7" (1=周一 ... 7=周日, 0 也表示周日)
+func parseScheduleDays(spec string) ([]int, error) {
+	var days []int
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		from, to := part, part
+		if i := strings.Index(part, "-"); i > 0 {
+			from, to = part[:i], part[i+1:]
+		}
+		start, err1 := strconv.Atoi(from)
+		end, err2 := strconv.Atoi(to)
+		if err1 != nil || err2 != nil || start < 0 || end > 7 || start > end {
+			return nil, fmt.Errorf("星期格式错误: %s", spec)
+		}
+		for d := start; d <= end; d++ {
+			days = append(days, d%7)
+		}
+	}
+	return days, nil
+}
+
+// ValidateScheduleRule 检查作息规则
+func ValidateScheduleRule(rule config.ScheduleRule) error {
+	if rule.Module != ModuleAll {
+		valid := false
+		for _, module := range scheduleModules {
+			if rule.Module == module {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("未知的作息模块: %s", rule.Module)
+		}
+	}
+	switch rule.Mode {
+	case ModeFull, ModeHarvestOnly, ModePaused:
+	default:
+		return fmt.Errorf("未知的运行模式: %s", rule.Mode)
+	}
+	if rule.Mode == ModeHarvestOnly && (rule.Module == ModuleTask || rule.Module == ModuleWarehouse) {
+		return fmt.Errorf("%s模块不支持 %s 模式", scheduleModuleName(rule.Module), ModeHarvestOnly)
+	}
+	if _, err := parseClock(rule.Start); err != nil {
+		return err
+	}
+	if _, err := parseClock(rule.End); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ruleMatches 规则在该时刻是否生效 (跨零点的时间段按开始那天的星期匹配)
+func ruleMatches(rule config.ScheduleRule, module string, t time.Time) bool {
+	if rule.Module != module && rule.Module != ModuleAll {
+		return false
+	}
+	start, err1 := parseClock(rule.Start)
+	end, err2 := parseClock(rule.End)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	minute := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	if start <= end {
+		if minute < start || minute >= end {
+			return false
+		}
+	} else if minute < end {
+		day = (day + 6) % 7 // 跨零点的后半段属于前一天的规则
+	} else if minute < start {
+		return false
+	}
+
+	if len(rule.Days) == 0 {
+		return true
+	}
+	for _, d := range rule.Days {
+		if time.Weekday(d) == day {
+			return true
+		}
+	}
+	return false
+}
+
+// ModeAt 模块在该时刻的运行模式和巡查间隔倍数 (按顺序第一条匹配的规则生效)
+func (s *ActivitySchedule) ModeAt(module string, t time.Time) (string, float64) {
+	for _, rule := range config.Current.Schedule {
+		if ruleMatches(rule, module, t) {
+			scale := rule.IntervalScale
+			if scale <= 0 {
+				scale = 1
+			}
+			return rule.Mode, scale
+		}
+	}
+	return ModeFull, 1
+}
+
+// ModeFor 模块当前的运行模式，模式切换时输出日志
+func (s *ActivitySchedule) ModeFor(module string) string {
+	mode, _ := s.ModeAt(module, time.Now())
+
+	s.mu.Lock()
+	last, ok := s.lastMode[module]
+	s.lastMode[module] = mode
+	s.mu.Unlock()
+
+	if ok && last != mode {
+		text := fmt.Sprintf("%s: %s → %s", scheduleModuleName(module), scheduleModeName(last), scheduleModeName(mode))
+		if effect := scheduleModeEffect(module, mode); effect != "" {
+			text += " (" + effect + ")"
+		}
+		utils.Log("作息", text)
+	}
+	return mode
+}
+
+// Interval 按当前作息调整巡查间隔
+func (s *ActivitySchedule) Interval(module string, base time.Duration) time.Duration {
+	_, scale := s.ModeAt(module, time.Now())
+	return time.Duration(float64(base) * scale)
+}
+
+// Restricts 是否有规则会让模块不以正常模式运行 (暂停或只收获)
+func (s *ActivitySchedule) Restricts(module string) bool {
+	for _, rule := range config.Current.Schedule {
+		if (rule.Module == module || rule.Module == ModuleAll) && rule.Mode != ModeFull {
+			return true
+		}
+	}
+	return false
+}
+
+// NextFullAt 该时刻之后模块最近恢复正常模式的时间 (已是正常模式时返回自身)
+func (s *ActivitySchedule) NextFullAt(module string, t time.Time) time.Time {
+	if mode, _ := s.ModeAt(module, t); mode == ModeFull {
+		return t
+	}
+
+	// 模式只会在规则的起止时间点变化，逐个检查未来一周内的时间点
+	dayStart := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	var candidates []time.Time
+	for day := 0; day <= 7; day++ {
+		for _, rule := range config.Current.Schedule {
+			for _, clock := range []string{rule.Start, rule.End} {
+				minute, err := parseClock(clock)
+				if err != nil {
+					continue
+				}
+				at := dayStart.AddDate(0, 0, day).Add(time.Duration(minute) * time.Minute)
+				if at.After(t) {
+					candidates = append(candidates, at)
+				}
+			}
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	for _, at := range candidates {
+		if mode, _ := s.ModeAt(module, at); mode == ModeFull {
+			return at
+		}
+	}
+	return t
+}
+
+// StatusText 状态栏显示的当前作息 (未配置作息时为空)
+func (s *ActivitySchedule) StatusText() string {
+	if len(config.Current.Schedule) == 0 {
+		return ""
+	}
+
+	now := time.Now()
+	parts := []string{}
+	for _, module := range scheduleModules {
+		mode, scale := s.ModeAt(module, now)
+		text := ""
+		if mode != ModeFull {
+			text = scheduleModeName(mode)
+		}
+		if scale != 1 {
+			text += fmt.Sprintf("x%g", scale)
+		}
+		if text != "" {
+			parts = append(parts, scheduleModuleName(module)+text)
+		}
+	}
+	if len(parts) == 0 {
+		return "作息:正常"
+	}
+	return "作息:" + strings.Join(parts, " ")
+}
+
+// StartStatusLoop 定时检查作息，变化时推送 scheduleChanged 事件 (状态栏据此刷新)
+func (s *ActivitySchedule) StartStatusLoop() {
+	s.mu.Lock()
+	if s.loopRunning {
+		s.mu.Unlock()
+		return
+	}
+	s.loopRunning = true
+	s.mu.Unlock()
+
+	go func() {
+		for s.loopRunning {
+			s.publishStatus()
+			time.Sleep(ScheduleStatusInterval)
+		}
+	}()
+}
+
+// StopStatusLoop 停止作息检查
+func (s *ActivitySchedule) StopStatusLoop() {
+	s.loopRunning = false
+}
+
+// publishStatus 作息文本变化时推送事件，并触发各模块的切换日志
+func (s *ActivitySchedule) publishStatus() {
+	for _, module := range scheduleModules {
+		s.ModeFor(module)
+	}
+	text := s.StatusText()
+
+	s.mu.Lock()
+	changed := text != s.lastStatus
+	s.lastStatus = text
+	s.mu.Unlock()
+
+	if changed {
+		network.Net.GetEvents().Emit("scheduleChanged", text)
+	}
+}
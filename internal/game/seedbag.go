@@ -0,0 +1,155 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+
+	"gofarm/internal/config"
+	"gofarm/internal/utils"
+)
+
+// BagSeed 背包中的种子
+type BagSeed struct {
+	SeedID     int64
+	Count      int64
+	Name       string
+	ExpPerHour float64 // 单块地每小时经验
+	IsEvent    bool    // 活动种子 (种子商店不出售)
+}
+
+// SeedAllocation 种子分配结果
+type SeedAllocation struct {
+	Seed    *BagSeed
+	LandIDs []int64
+}
+
+// seedExpPerHour 计算单块地每小时经验 (按 Plant.json 的生长时间)
+func seedExpPerHour(plant *Plant) float64 {
+	if plant == nil {
+		return 0
+	}
+	growTime := Config.GetPlantGrowTime(plant.ID)
+	if growTime <= 0 {
+		return 0
+	}
+	return float64(plant.Exp) * 3600 / float64(growTime)
+}
+
+// GetBagSeeds 查询背包中可种植的种子
+func (fm *FarmManager) GetBagSeeds() ([]*BagSeed, error) {
+	bagReply, err := Warehouse.GetBag()
+	if err != nil {
+		return nil, err
+	}
+
+	// 同一种子可能分多条记录 (不同过期时间)，按ID合并
+	seedMap := make(map[int64]*BagSeed)
+	var seeds []*BagSeed
+	for _, item := range Warehouse.getBagItems(bagReply) {
+		if item == nil || item.Count <= 0 {
+			continue
+		}
+		plant := Config.GetPlantBySeedID(int(item.Id))
+		if plant == nil {
+			continue
+		}
+		if seed, ok := seedMap[item.Id]; ok {
+			seed.Count += item.Count
+			continue
+		}
+		seed := &BagSeed{
+			SeedID:     item.Id,
+			Count:      item.Count,
+			Name:       plant.Name,
+			ExpPerHour: seedExpPerHour(plant),
+			IsEvent:    !Warehouse.isShopSeedID(item.Id),
+		}
+		seedMap[item.Id] = seed
+		seeds = append(seeds, seed)
+	}
+
+	return seeds, nil
+}
+
+// AllocateBagSeeds 按配置策略把背包种子分配到土地，返回分配结果和仍需购买种子的土地
+func (fm *FarmManager) AllocateBagSeeds(seeds []*BagSeed, landIds []int64, bestExpPerHour float64) ([]*SeedAllocation, []int64) {
+	lands := fm.landInfoMap()
+	minExpPerHour := bestExpPerHour * (1 - config.Current.BagSeedExpTolerance/100)
+
+	var candidates []*BagSeed
+	for _, seed := range seeds {
+		if seed.IsEvent && config.Current.AlwaysUseEventSeeds {
+			candidates = append(candidates, seed)
+			continue
+		}
+		if bestExpPerHour <= 0 || seed.ExpPerHour >= minExpPerHour {
+			candidates = append(candidates, seed)
+		}
+	}
+
+	// 活动种子优先，其次按经验效率从高到低
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].IsEvent != candidates[j].IsEvent && config.Current.AlwaysUseEventSeeds {
+			return candidates[i].IsEvent
+		}
+		return candidates[i].ExpPerHour > candidates[j].ExpPerHour
+	})
+
+	remaining := landIds
+	var allocations []*SeedAllocation
+	for _, seed := range candidates {
+		if len(remaining) == 0 {
+			break
+		}
+		// 大作物一颗种子占用多块地
+		n := coverLands(seed.SeedID, remaining, lands, seed.Count)
+		if n == 0 {
+			continue
+		}
+		allocations = append(allocations, &SeedAllocation{
+			Seed:    seed,
+			LandIDs: remaining[:n],
+		})
+		remaining = remaining[n:]
+	}
+
+	return allocations, remaining
+}
+
+// plantFromBag 使用背包种子种植，返回已种植的土地和剩余土地
+func (fm *FarmManager) plantFromBag(landIds []int64, bestExpPerHour float64) ([]int64, []int64) {
+	seeds, err := fm.GetBagSeeds()
+	if err != nil {
+		utils.LogWarn("种植", fmt.Sprintf("查询背包种子失败: %v", err))
+		return nil, landIds
+	}
+	if len(seeds) == 0 {
+		return nil, landIds
+	}
+
+	allocations, remaining := fm.AllocateBagSeeds(seeds, landIds, bestExpPerHour)
+
+	var plantedLands []int64
+	for _, alloc := range allocations {
+		planted, slaves, _ := fm.PlantSeeds(alloc.Seed.SeedID, alloc.LandIDs)
+		// 没种上且没被大作物占用的土地留给后续购买的种子
+		for _, landID := range alloc.LandIDs {
+			if !containsInt64(planted, landID) && !containsInt64(slaves, landID) {
+				remaining = append(remaining, landID)
+			}
+		}
+		if len(planted) == 0 {
+			continue
+		}
+		plantedLands = append(plantedLands, planted...)
+
+		kind := ""
+		if alloc.Seed.IsEvent {
+			kind = "活动"
+		}
+		utils.Log("种植", fmt.Sprintf("使用背包%s种子 %s x%d (每小时 %.2f 经验)",
+			kind, alloc.Seed.Name, len(planted), alloc.Seed.ExpPerHour))
+	}
+
+	return plantedLands, remaining
+}
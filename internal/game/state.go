@@ -0,0 +1,366 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"gofarm/internal/config"
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// 状态快照目录 (每个账号一个文件: state/<GID>.json)
+const StateDir = "state"
+
+// 状态快照保存间隔
+const StateSaveInterval = 1 * time.Minute
+
+// StateSnapshot 账号状态快照
+type StateSnapshot struct {
+	GID             int64             `json:"gid"`
+	Date            string            `json:"date"` // 保存时的日期, 当天的限制/计数只在同一天恢复
+	SavedAt         int64             `json:"savedAt"`
+	Level           int               `json:"level"`
+	Exp             int64             `json:"exp"` // 总经验
+	Lands           []json.RawMessage `json:"lands"`
+	OperationLimits []json.RawMessage `json:"operationLimits"` // 当天的操作限制
+	Farm            farmSnapshot      `json:"farm"`
+	Friend          friendSnapshot    `json:"friend"`
+	Theft           theftSnapshot     `json:"theft"`
+	Budget          map[string]int64  `json:"budget"` // 类别 -> 当天已支出
+}
+
+// farmSnapshot 自家农场的状态
+type farmSnapshot struct {
+	GuardAttempts  int64 `json:"guardAttempts"`
+	GuardSuccesses int64 `json:"guardSuccesses"`
+	GuardRetried   int64 `json:"guardRetried"`
+	GuardTotalLag  int64 `json:"guardTotalLag"`
+}
+
+// friendSnapshot 好友系统的状态
+type friendSnapshot struct {
+	ExpTracker   map[int32]int64  `json:"expTracker"`
+	ExpExhausted []int32          `json:"expExhausted"`
+	Names        map[int64]string `json:"names"`
+}
+
+// theftLandSnapshot 单块地的被偷状态
+type theftLandSnapshot struct {
+	PlantKey string  `json:"plantKey"`
+	Stealers []int64 `json:"stealers"`
+	Left     int64   `json:"left"`
+}
+
+// theftSnapshot 被偷监控的状态
+type theftSnapshot struct {
+	Lands   map[int64]*theftLandSnapshot `json:"lands"`
+	Records []*TheftRecord               `json:"records"` // 当天记录
+	Stats   []*ThiefStats                `json:"stats"`
+}
+
+// StateStore 账号状态持久化: 定时保存快照, 启动时恢复
+type StateStore struct {
+	gid         int64
+	loopRunning bool
+	mu          sync.Mutex
+}
+
+var State *StateStore
+
+func init() {
+	State = &StateStore{}
+}
+
+// currentGID 当前登录账号的GID (未登录时为0)
+func currentGID() int64 {
+	return network.Net.GetUserState().GID
+}
+
+// stateFilePath 账号的快照文件路径
+func stateFilePath(gid int64) string {
+	return filepath.Join(StateDir, fmt.Sprintf("%d.json", gid))
+}
+
+// marshalProtoList 把proto消息列表编码为JSON
+func marshalProtoList[T proto.Message](msgs []T) []json.RawMessage {
+	result := make([]json.RawMessage, 0, len(msgs))
+	for _, msg := range msgs {
+		data, err := protojson.Marshal(msg)
+		if err != nil {
+			continue
+		}
+		result = append(result, data)
+	}
+	return result
+}
+
+// unmarshalProtoList 从JSON解码proto消息列表
+func unmarshalProtoList[T proto.Message](raw []json.RawMessage, newMsg func() T) []T {
+	result := make([]T, 0, len(raw))
+	for _, data := range raw {
+		msg := newMsg()
+		if err := protojson.Unmarshal(data, msg); err != nil {
+			continue
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+// takeSnapshot 收集各模块的当前状态
+func (ss *StateStore) takeSnapshot(gid int64) *StateSnapshot {
+	snap := &StateSnapshot{
+		GID:             gid,
+		Date:            getGameDateKey(),
+		SavedAt:         utils.GetServerTimeSec(),
+		Level:           network.Net.GetUserState().Level,
+		Exp:             network.Net.GetUserState().Exp,
+		Lands:           marshalProtoList(Farm.GetLastLands()),
+		OperationLimits: marshalProtoList(OpLimits.List()),
+	}
+
+	g := Farm.guard
+	g.mu.Lock()
+	snap.Farm.GuardAttempts, snap.Farm.GuardSuccesses = g.attempts, g.successes
+	snap.Farm.GuardRetried, snap.Farm.GuardTotalLag = g.retried, g.totalLag
+	g.mu.Unlock()
+
+	Friend.mu.RLock()
+	snap.Friend.ExpTracker = make(map[int32]int64, len(Friend.expTracker))
+	for opId, times := range Friend.expTracker {
+		snap.Friend.ExpTracker[opId] = times
+	}
+	for opId, exhausted := range Friend.expExhausted {
+		if exhausted {
+			snap.Friend.ExpExhausted = append(snap.Friend.ExpExhausted, opId)
+		}
+	}
+	snap.Friend.Names = make(map[int64]string, len(Friend.friendNames))
+	for gid, name := range Friend.friendNames {
+		snap.Friend.Names[gid] = name
+	}
+	Friend.mu.RUnlock()
+
+	Theft.mu.RLock()
+	snap.Theft.Lands = make(map[int64]*theftLandSnapshot, len(Theft.lands))
+	for landID, state := range Theft.lands {
+		stealers := make([]int64, 0, len(state.stealers))
+		for gid := range state.stealers {
+			stealers = append(stealers, gid)
+		}
+		snap.Theft.Lands[landID] = &theftLandSnapshot{PlantKey: state.plantKey, Stealers: stealers, Left: state.left}
+	}
+	snap.Theft.Records = append([]*TheftRecord(nil), Theft.records...)
+	Theft.mu.RUnlock()
+	snap.Theft.Stats = Theft.GetThiefStats()
+
+	snap.Budget = Budget.spentToday()
+
+	return snap
+}
+
+// applySnapshot 把快照恢复到各模块 (当天的限制和计数只在同一天恢复)
+func (ss *StateStore) applySnapshot(snap *StateSnapshot) {
+	sameDay := snap.Date == getGameDateKey()
+
+	lands := unmarshalProtoList(snap.Lands, func() *plantpb.LandInfo { return &plantpb.LandInfo{} })
+	if sameDay {
+		OpLimits.Update(unmarshalProtoList(snap.OperationLimits, func() *plantpb.OperationLimit { return &plantpb.OperationLimit{} }))
+	}
+
+	Farm.mu.Lock()
+	if len(Farm.lastLands) == 0 {
+		Farm.lastLands = lands
+	}
+	Farm.mu.Unlock()
+	g := Farm.guard
+	g.mu.Lock()
+	g.attempts += snap.Farm.GuardAttempts
+	g.successes += snap.Farm.GuardSuccesses
+	g.retried += snap.Farm.GuardRetried
+	g.totalLag += snap.Farm.GuardTotalLag
+	g.mu.Unlock()
+
+	Friend.mu.Lock()
+	for gid, name := range snap.Friend.Names {
+		if _, ok := Friend.friendNames[gid]; !ok {
+			Friend.friendNames[gid] = name
+		}
+	}
+	if sameDay {
+		for opId, times := range snap.Friend.ExpTracker {
+			Friend.expTracker[opId] = times
+		}
+		for _, opId := range snap.Friend.ExpExhausted {
+			Friend.expExhausted[opId] = true
+		}
+	}
+	Friend.mu.Unlock()
+
+	Theft.mu.Lock()
+	for landID, state := range snap.Theft.Lands {
+		if state == nil {
+			continue
+		}
+		stealers := make(map[int64]bool, len(state.Stealers))
+		for _, gid := range state.Stealers {
+			stealers[gid] = true
+		}
+		Theft.lands[landID] = &landTheftState{plantKey: state.PlantKey, stealers: stealers, left: state.Left}
+	}
+	for _, stats := range snap.Theft.Stats {
+		if stats != nil {
+			Theft.stats[stats.GID] = stats
+		}
+	}
+	if sameDay {
+		Theft.records = append(snap.Theft.Records, Theft.records...)
+	}
+	Theft.mu.Unlock()
+
+	if sameDay {
+		Budget.restoreSpent(snap.Budget)
+	}
+}
+
+// LoadSnapshot 读取账号的状态快照 (gid 为0时读取 state 目录下唯一的快照)
+func LoadSnapshot(gid int64) (*StateSnapshot, error) {
+	path := stateFilePath(gid)
+	if gid == 0 {
+		// 同目录下还有其他状态文件 (如 friends-<gid>.json), 只认纯数字文件名
+		var snapshots []string
+		matches, _ := filepath.Glob(filepath.Join(StateDir, "*.json"))
+		for _, match := range matches {
+			name := strings.TrimSuffix(filepath.Base(match), ".json")
+			if _, err := strconv.ParseInt(name, 10, 64); err == nil {
+				snapshots = append(snapshots, match)
+			}
+		}
+		if len(snapshots) != 1 {
+			return nil, fmt.Errorf("%s 下有 %d 个账号的状态快照, 请指定账号GID", StateDir, len(snapshots))
+		}
+		path = snapshots[0]
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var snap StateSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// PlotCount 快照中已解锁的地块数
+func (snap *StateSnapshot) PlotCount() int {
+	return CountPlots(unmarshalProtoList(snap.Lands, func() *plantpb.LandInfo { return &plantpb.LandInfo{} }))
+}
+
+// Restore 启动时恢复账号的状态快照，返回是否恢复成功
+func (ss *StateStore) Restore(gid int64) bool {
+	ss.mu.Lock()
+	ss.gid = gid
+	ss.mu.Unlock()
+
+	if !config.Current.WarmStart || gid == 0 {
+		return false
+	}
+
+	// 收获账本历史
+	if err := Ledger.LoadFromFile(gid); err != nil && !os.IsNotExist(err) {
+		utils.LogWarn("状态", fmt.Sprintf("加载收获账本失败: %v", err))
+	}
+
+	data, err := os.ReadFile(stateFilePath(gid))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			utils.LogWarn("状态", fmt.Sprintf("读取状态快照失败: %v", err))
+		}
+		return false
+	}
+	var snap StateSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		utils.LogWarn("状态", fmt.Sprintf("解析状态快照失败: %v", err))
+		return false
+	}
+	if snap.GID != gid {
+		return false
+	}
+
+	ss.applySnapshot(&snap)
+
+	age := time.Duration(utils.GetServerTimeSec()-snap.SavedAt) * time.Second
+	text := fmt.Sprintf("已恢复 %s 前的状态快照: %d 块地, %d 位好友, %d 个被偷记录", age.Round(time.Second),
+		len(snap.Lands), len(snap.Friend.Names), len(snap.Theft.Records))
+	if snap.Date == getGameDateKey() && len(snap.Friend.ExpExhausted) > 0 {
+		text += fmt.Sprintf(", 今日经验已耗尽的操作 %d 个", len(snap.Friend.ExpExhausted))
+	}
+	utils.Log("状态", text)
+	return true
+}
+
+// Save 保存当前状态快照 (先写临时文件再替换, 避免写一半时退出损坏快照)
+func (ss *StateStore) Save() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.gid == 0 {
+		return
+	}
+
+	data, err := json.MarshalIndent(ss.takeSnapshot(ss.gid), "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(StateDir, 0755); err != nil {
+		utils.LogWarn("状态", fmt.Sprintf("创建状态目录失败: %v", err))
+		return
+	}
+	path := stateFilePath(ss.gid)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		utils.LogWarn("状态", fmt.Sprintf("保存状态快照失败: %v", err))
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		utils.LogWarn("状态", fmt.Sprintf("保存状态快照失败: %v", err))
+	}
+}
+
+// StartAutoSave 启动定时保存
+func (ss *StateStore) StartAutoSave() {
+	ss.mu.Lock()
+	if ss.loopRunning {
+		ss.mu.Unlock()
+		return
+	}
+	ss.loopRunning = true
+	ss.mu.Unlock()
+
+	go func() {
+		for ss.loopRunning {
+			time.Sleep(StateSaveInterval)
+			if !ss.loopRunning {
+				break
+			}
+			ss.Save()
+		}
+	}()
+}
+
+// StopAutoSave 停止定时保存并保存最后一次快照
+func (ss *StateStore) StopAutoSave() {
+	ss.loopRunning = false
+	ss.Save()
+}
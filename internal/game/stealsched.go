@@ -0,0 +1,242 @@
+package game
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"gofarm/internal/config"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/friendpb"
+)
+
+// stealTarget 即将成熟的好友农场
+type stealTarget struct {
+	gid     int64
+	name    string
+	fruitID int64
+	value   int64           // 果实单价
+	ripeAt  int64           // 成熟时间 (服务器时间, 秒)
+	summary *friendpb.Plant // 好友列表中的农场摘要 (拜访后存入农场缓存)
+	index   int
+}
+
+// stealQueue 按成熟时间排序的优先队列: 先成熟的先拜访, 果实价值只决定同一秒成熟的先后;
+// 定时器触发时已成熟的一批农场再整体按价值排序 (见 popDue)
+type stealQueue []*stealTarget
+
+func (q stealQueue) Len() int { return len(q) }
+
+func (q stealQueue) Less(i, j int) bool {
+	if q[i].ripeAt != q[j].ripeAt {
+		return q[i].ripeAt < q[j].ripeAt
+	}
+	return q[i].value > q[j].value
+}
+
+func (q stealQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *stealQueue) Push(x interface{}) {
+	t := x.(*stealTarget)
+	t.index = len(*q)
+	*q = append(*q, t)
+}
+
+func (q *stealQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	t := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return t
+}
+
+// StealScheduler 定时偷菜: 按好友列表中的成熟倒计时，在作物成熟时立即拜访
+type StealScheduler struct {
+	queue     stealQueue
+	targets   map[int64]*stealTarget // GID -> 队列中的目标
+	visited   map[int64]int64        // GID -> 已拜访的成熟时间 (避免重复拜访)
+	timer     *time.Timer
+	firing    bool
+	lastVisit time.Time
+	mu        sync.Mutex
+}
+
+var Stealer *StealScheduler
+
+func init() {
+	Stealer = &StealScheduler{
+		targets: make(map[int64]*stealTarget),
+		visited: make(map[int64]int64),
+	}
+}
+
+// fruitValue 果实单价 (物品配置中没有价格时按种子价格估算)
+func fruitValue(fruitID int64) int64 {
+	if info := Config.GetItemInfoByID(int(fruitID)); info != nil && info.Price > 0 {
+		return info.Price
+	}
+	if plant := Config.GetPlantByFruitID(int(fruitID)); plant != nil {
+		if info := Config.GetItemInfoByID(plant.SeedID); info != nil {
+			return info.Price
+		}
+	}
+	return 0
+}
+
+// Update 根据好友列表中的成熟倒计时更新队列
+func (ss *StealScheduler) Update(friends []*friendpb.GameFriend) {
+	if !config.Current.StealScheduler {
+		return
+	}
+	now := utils.GetServerTimeSec()
+
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	for gid, ripeAt := range ss.visited {
+		if now-ripeAt > 24*3600 {
+			delete(ss.visited, gid)
+		}
+	}
+
+	for _, friend := range friends {
+		if friend == nil || friend.Plant == nil || friend.Plant.RipeTimeSec <= 0 {
+			continue
+		}
+		if !FriendRules.ShouldVisit(friend) || !FriendRules.RuleFor(friend.Gid, friend.Name).AllowSteal() {
+			continue
+		}
+		ripeAt := now + friend.Plant.RipeTimeSec
+		if last, ok := ss.visited[friend.Gid]; ok && ripeAt-last <= 5 {
+			continue // 已拜访过这一批成熟的作物 (倒计时有几秒误差)
+		}
+
+		if t, ok := ss.targets[friend.Gid]; ok {
+			t.name = friend.Name
+			t.ripeAt = ripeAt
+			t.fruitID = friend.Plant.RipeFruitId
+			t.value = fruitValue(friend.Plant.RipeFruitId)
+			t.summary = friend.Plant
+			heap.Fix(&ss.queue, t.index)
+			continue
+		}
+		t := &stealTarget{
+			gid:     friend.Gid,
+			name:    friend.Name,
+			fruitID: friend.Plant.RipeFruitId,
+			value:   fruitValue(friend.Plant.RipeFruitId),
+			ripeAt:  ripeAt,
+			summary: friend.Plant,
+		}
+		ss.targets[friend.Gid] = t
+		heap.Push(&ss.queue, t)
+	}
+
+	ss.rearm()
+}
+
+// rearm 按队首的成熟时间重新设置定时器 (调用方持有锁)
+func (ss *StealScheduler) rearm() {
+	if ss.timer != nil {
+		ss.timer.Stop()
+		ss.timer = nil
+	}
+	if len(ss.queue) == 0 || ss.firing {
+		return
+	}
+	wait := time.Duration(ss.queue[0].ripeAt*1000-utils.GetServerTimeMs())*time.Millisecond + config.Current.StealRipeDelay
+	if wait < 0 {
+		wait = 0
+	}
+	ss.timer = time.AfterFunc(wait, ss.fire)
+}
+
+// popDue 取出所有已成熟的目标，按果实价值降序 (调用方持有锁)
+func (ss *StealScheduler) popDue() []*stealTarget {
+	nowMs := utils.GetServerTimeMs()
+	var due []*stealTarget
+	for len(ss.queue) > 0 && ss.queue[0].ripeAt*1000 <= nowMs {
+		t := heap.Pop(&ss.queue).(*stealTarget)
+		delete(ss.targets, t.gid)
+		ss.visited[t.gid] = t.ripeAt
+		due = append(due, t)
+	}
+	sort.SliceStable(due, func(i, j int) bool { return due[i].value > due[j].value })
+	return due
+}
+
+// fire 拜访所有已成熟的好友农场
+func (ss *StealScheduler) fire() {
+	ss.mu.Lock()
+	if ss.firing {
+		ss.mu.Unlock()
+		return
+	}
+	ss.firing = true
+	due := ss.popDue()
+	ss.mu.Unlock()
+
+	defer func() {
+		ss.mu.Lock()
+		ss.firing = false
+		ss.rearm()
+		ss.mu.Unlock()
+	}()
+
+	for i, t := range due {
+		if Friend.isLimitReached(OpSteal) {
+			utils.Log("定时偷菜", fmt.Sprintf("今日偷菜次数已用完, 跳过 %d 个成熟的农场", len(due)-i))
+			return
+		}
+		if Schedule.ModeFor(ModuleFriend) == ModePaused {
+			return
+		}
+
+		// 两次拜访之间至少间隔 StealMinGap
+		if gap := time.Since(ss.lastVisit); gap < config.Current.StealMinGap {
+			time.Sleep(config.Current.StealMinGap - gap)
+		}
+		ss.lastVisit = time.Now()
+
+		utils.Log("定时偷菜", fmt.Sprintf("%s 的%s 已成熟 (单价 %d)", t.name, Config.GetFruitName(int(t.fruitID)), t.value))
+		Friend.CheckFriendFarm(&friendpb.GameFriend{Gid: t.gid, Name: t.name, Plant: t.currentSummary()})
+	}
+}
+
+// currentSummary 入队时的农场摘要，成熟倒计时换算到现在 (农场缓存按摘要判断农场是否变化)
+func (t *stealTarget) currentSummary() *friendpb.Plant {
+	if t.summary == nil {
+		return nil
+	}
+	summary := proto.Clone(t.summary).(*friendpb.Plant)
+	summary.RipeTimeSec = max(t.ripeAt-utils.GetServerTimeSec(), 0)
+	return summary
+}
+
+// Stop 取消定时器并清空队列
+func (ss *StealScheduler) Stop() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.timer != nil {
+		ss.timer.Stop()
+		ss.timer = nil
+	}
+	ss.queue = nil
+	ss.targets = make(map[int64]*stealTarget)
+}
+
+// Pending 队列中等待成熟的农场数
+func (ss *StealScheduler) Pending() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return len(ss.queue)
+}
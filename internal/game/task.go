@@ -43,7 +43,7 @@ func (tm *TaskManager) GetTaskInfo() (*taskpb.TaskInfoReply, error) {
 	req := &taskpb.TaskInfoRequest{}
 	resp := &taskpb.TaskInfoReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.taskpb.TaskService", "TaskInfo", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.taskpb.TaskService", "TaskInfo", req, resp, 10*time.Second)
 	if err != nil {
 		return nil, err
 	}
@@ -56,6 +56,13 @@ func (tm *TaskManager) GetTaskInfo() (*taskpb.TaskInfoReply, error) {
 	return resp, nil
 }
 
+// resetDaily 游戏日变化时清空缓存的任务信息 (每日任务已刷新)
+func (tm *TaskManager) resetDaily() {
+	tm.mu.Lock()
+	tm.taskInfo = nil
+	tm.mu.Unlock()
+}
+
 // ClaimTaskReward 领取单个任务奖励
 func (tm *TaskManager) ClaimTaskReward(taskID int64, doShared bool) (*taskpb.ClaimTaskRewardReply, error) {
 	req := &taskpb.ClaimTaskRewardRequest{
@@ -64,7 +71,7 @@ func (tm *TaskManager) ClaimTaskReward(taskID int64, doShared bool) (*taskpb.Cla
 	}
 	resp := &taskpb.ClaimTaskRewardReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.taskpb.TaskService", "ClaimTaskReward", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.taskpb.TaskService", "ClaimTaskReward", req, resp, 10*time.Second)
 	if err != nil {
 		return nil, err
 	}
@@ -87,7 +94,7 @@ func (tm *TaskManager) BatchClaimTaskReward(taskIDs []int64, doShared bool) (*ta
 	}
 	resp := &taskpb.BatchClaimTaskRewardReply{}
 	
-	err := network.Net.SendProtoMessage("gamepb.taskpb.TaskService", "BatchClaimTaskReward", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.taskpb.TaskService", "BatchClaimTaskReward", req, resp, 10*time.Second)
 	if err != nil {
 		return nil, err
 	}
@@ -198,6 +205,11 @@ func (tm *TaskManager) CheckAndClaimTasks() {
 	tm.isChecking = true
 	defer func() { tm.isChecking = false }()
 
+	// 作息: 暂停时不领取任务
+	if Schedule.ModeFor(ModuleTask) == ModePaused {
+		return
+	}
+
 	// 获取任务信息
 	reply, err := tm.GetTaskInfo()
 	if err != nil {
@@ -353,7 +365,7 @@ func (tm *TaskManager) StartTaskCheckLoop() {
 	go func() {
 		for tm.loopRunning {
 			// 等待间隔时间
-			time.Sleep(TaskCheckInterval)
+			time.Sleep(Schedule.Interval(ModuleTask, TaskCheckInterval))
 			
 			if !tm.loopRunning {
 				break
@@ -0,0 +1,306 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"gofarm/internal/network"
+	"gofarm/internal/utils"
+	"gofarm/proto/gamepb/plantpb"
+)
+
+// TheftRecord 一次被偷记录
+type TheftRecord struct {
+	Time      int64  `json:"time"`
+	ThiefGID  int64  `json:"thiefGid"`
+	ThiefName string `json:"thiefName"`
+	LandID    int64  `json:"landId"`
+	PlantID   int64  `json:"plantId"`
+	PlantName string `json:"plantName"`
+	Lost      int64  `json:"lost"` // 被偷果实数
+}
+
+// ThiefStats 单个好友的偷菜统计
+type ThiefStats struct {
+	GID      int64  `json:"gid"`
+	Name     string `json:"name"`
+	Times    int64  `json:"times"`
+	Lost     int64  `json:"lost"`
+	LastTime int64  `json:"lastTime"`
+}
+
+// landTheftState 单块地的被偷状态
+type landTheftState struct {
+	plantKey string
+	stealers map[int64]bool
+	left     int64
+}
+
+// TheftMonitor 自家农场被偷监控
+type TheftMonitor struct {
+	loopRunning   bool
+	networkEvents *network.EventEmitter
+	lands         map[int64]*landTheftState
+	records       []*TheftRecord // 当天记录
+	stats         map[int64]*ThiefStats
+	dateKey       string
+	mu            sync.RWMutex
+}
+
+var Theft *TheftMonitor
+
+func init() {
+	Theft = &TheftMonitor{
+		networkEvents: network.Net.GetEvents(),
+		lands:         make(map[int64]*landTheftState),
+		stats:         make(map[int64]*ThiefStats),
+		dateKey:       getGameDateKey(),
+	}
+}
+
+// plantKeyOf 作物唯一键 (土地重新种植后变化)
+func plantKeyOf(plant *plantpb.PlantInfo) string {
+	beginTime := int64(0)
+	if len(plant.Phases) > 0 {
+		beginTime = plant.Phases[0].BeginTime
+	}
+	return fmt.Sprintf("%d:%d", plant.Id, beginTime)
+}
+
+// ObserveLands 对比自家土地的偷菜者和剩余果实，记录新的被偷
+func (tm *TheftMonitor) ObserveLands(lands []*plantpb.LandInfo) {
+	now := utils.GetServerTimeSec()
+
+	tm.mu.Lock()
+	var newRecords []*TheftRecord
+	for _, land := range lands {
+		if land == nil || land.Plant == nil {
+			continue
+		}
+		plant := land.Plant
+		key := plantKeyOf(plant)
+
+		state, ok := tm.lands[land.Id]
+		if !ok {
+			// 首次见到该土地 (如冷启动后): 已有的偷菜者作为基线，不知道何时偷的，不记录
+			state = &landTheftState{
+				plantKey: key,
+				stealers: make(map[int64]bool),
+				left:     plant.LeftFruitNum,
+			}
+			for _, gid := range plant.Stealers {
+				state.stealers[gid] = true
+			}
+			tm.lands[land.Id] = state
+			continue
+		}
+		if state.plantKey != key {
+			// 重新种植的作物: 之后出现的偷菜者都是新的，被偷数量按偷菜者平均分摊
+			state = &landTheftState{
+				plantKey: key,
+				stealers: make(map[int64]bool),
+				left:     plant.FruitNum,
+			}
+			tm.lands[land.Id] = state
+		}
+
+		var newStealers []int64
+		for _, gid := range plant.Stealers {
+			if !state.stealers[gid] {
+				state.stealers[gid] = true
+				newStealers = append(newStealers, gid)
+			}
+		}
+
+		lost := state.left - plant.LeftFruitNum
+		if plant.LeftFruitNum > 0 || lost > 0 {
+			state.left = plant.LeftFruitNum
+		}
+		if len(newStealers) == 0 {
+			continue
+		}
+		if lost < 0 {
+			lost = 0
+		}
+
+		for i, gid := range newStealers {
+			share := lost / int64(len(newStealers))
+			if i == 0 {
+				share += lost % int64(len(newStealers))
+			}
+			newRecords = append(newRecords, &TheftRecord{
+				Time:      now,
+				ThiefGID:  gid,
+				ThiefName: Friend.GetFriendName(gid),
+				LandID:    land.Id,
+				PlantID:   plant.Id,
+				PlantName: Config.GetPlantName(int(plant.Id)),
+				Lost:      share,
+			})
+		}
+	}
+
+	for _, rec := range newRecords {
+		tm.records = append(tm.records, rec)
+		stats, ok := tm.stats[rec.ThiefGID]
+		if !ok {
+			stats = &ThiefStats{GID: rec.ThiefGID}
+			tm.stats[rec.ThiefGID] = stats
+		}
+		stats.Name = rec.ThiefName
+		stats.Times++
+		stats.Lost += rec.Lost
+		stats.LastTime = rec.Time
+	}
+	tm.mu.Unlock()
+
+	// 先记录偷菜者 (同一推送中被清除的草虫归给刚来过的好友)
+	for _, rec := range newRecords {
+		Reciprocity.RecordTheft(rec.ThiefGID, rec.Lost)
+		utils.Log("防偷", fmt.Sprintf("%s 偷了土地#%d 的%s x%d", rec.ThiefName, rec.LandID, rec.PlantName, rec.Lost))
+	}
+	Reciprocity.ObserveLands(lands)
+}
+
+// handleLandsNotify 处理土地变化推送
+func (tm *TheftMonitor) handleLandsNotify(data interface{}) {
+	body, ok := data.([]byte)
+	if !ok {
+		return
+	}
+	var notify plantpb.LandsNotify
+	if err := proto.Unmarshal(body, &notify); err != nil {
+		return
+	}
+
+	// 只关心自己的农场
+	gid := network.Net.GetUserState().GID
+	if notify.HostGid != 0 && notify.HostGid != gid {
+		return
+	}
+	tm.ObserveLands(MasterLands(notify.Lands))
+}
+
+// GetThiefStats 获取所有偷菜者统计，按被偷数量降序
+func (tm *TheftMonitor) GetThiefStats() []*ThiefStats {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	result := make([]*ThiefStats, 0, len(tm.stats))
+	for _, stats := range tm.stats {
+		copied := *stats
+		result = append(result, &copied)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Lost > result[j].Lost
+	})
+	return result
+}
+
+// GetTheftCount 获取某位好友偷我们的次数 (供好友系统排序使用)
+func (tm *TheftMonitor) GetTheftCount(gid int64) int64 {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	if stats, ok := tm.stats[gid]; ok {
+		return stats.Times
+	}
+	return 0
+}
+
+// WriteDailySummary 输出并保存当天的被偷汇总
+func (tm *TheftMonitor) WriteDailySummary() {
+	tm.mu.RLock()
+	dateKey := tm.dateKey
+	records := append([]*TheftRecord(nil), tm.records...)
+	tm.mu.RUnlock()
+
+	if len(records) == 0 {
+		return
+	}
+
+	// 按好友汇总当天数据
+	daily := make(map[int64]*ThiefStats)
+	var order []int64
+	for _, rec := range records {
+		stats, ok := daily[rec.ThiefGID]
+		if !ok {
+			stats = &ThiefStats{GID: rec.ThiefGID, Name: rec.ThiefName}
+			daily[rec.ThiefGID] = stats
+			order = append(order, rec.ThiefGID)
+		}
+		stats.Times++
+		stats.Lost += rec.Lost
+		stats.LastTime = rec.Time
+	}
+
+	utils.Log("防偷", fmt.Sprintf("%s 被偷汇总: %d 次", dateKey, len(records)))
+	for _, gid := range order {
+		stats := daily[gid]
+		utils.Log("防偷", fmt.Sprintf("  %s (GID:%d) 偷了 %d 次, 共 %d 个果实", stats.Name, gid, stats.Times, stats.Lost))
+	}
+
+	summary := struct {
+		Date    string         `json:"date"`
+		Records []*TheftRecord `json:"records"`
+	}{dateKey, records}
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(LedgerDir, 0755); err != nil {
+		return
+	}
+	path := filepath.Join(LedgerDir, fmt.Sprintf("theft-%s.json", dateKey))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		utils.LogWarn("防偷", fmt.Sprintf("保存被偷汇总失败: %v", err))
+	}
+}
+
+// checkDayRollover 跨天时输出前一天的汇总并清空当天记录
+func (tm *TheftMonitor) checkDayRollover() {
+	today := getGameDateKey()
+	tm.mu.RLock()
+	changed := today != tm.dateKey
+	tm.mu.RUnlock()
+	if !changed {
+		return
+	}
+
+	tm.WriteDailySummary()
+
+	tm.mu.Lock()
+	tm.dateKey = today
+	tm.records = nil
+	tm.mu.Unlock()
+}
+
+// StartTheftMonitor 启动被偷监控
+func (tm *TheftMonitor) StartTheftMonitor() {
+	if tm.loopRunning {
+		return
+	}
+	tm.loopRunning = true
+
+	tm.networkEvents.On("landsChanged", tm.handleLandsNotify)
+
+	go func() {
+		for tm.loopRunning {
+			time.Sleep(1 * time.Minute)
+			tm.checkDayRollover()
+		}
+	}()
+}
+
+// StopTheftMonitor 停止被偷监控并保存当天汇总
+func (tm *TheftMonitor) StopTheftMonitor() {
+	tm.loopRunning = false
+	tm.WriteDailySummary()
+}
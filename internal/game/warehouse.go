@@ -24,6 +24,7 @@ type WarehouseManager struct {
 	loopRunning   bool
 	networkEvents *network.EventEmitter
 	fruitIDSet    map[int64]bool // 果实ID集合
+	shopSeedIDSet map[int64]bool // 商店出售的种子ID集合
 	mu            sync.RWMutex
 }
 
@@ -36,6 +37,7 @@ func init() {
 	Warehouse = &WarehouseManager{
 		networkEvents: network.Net.GetEvents(),
 		fruitIDSet:    make(map[int64]bool),
+		shopSeedIDSet: make(map[int64]bool),
 	}
 
 	// 加载果实ID数据
@@ -56,6 +58,7 @@ func (wm *WarehouseManager) loadFruitIDs() {
 	var seedShopData struct {
 		Rows []struct {
 			FruitID int64 `json:"fruitId"`
+			SeedID  int64 `json:"seedId"`
 		} `json:"rows"`
 	}
 
@@ -71,6 +74,9 @@ func (wm *WarehouseManager) loadFruitIDs() {
 			wm.fruitIDSet[row.FruitID] = true
 			validCount++
 		}
+		if row.SeedID > 0 {
+			wm.shopSeedIDSet[row.SeedID] = true
+		}
 	}
 	wm.mu.Unlock()
 
@@ -84,12 +90,19 @@ func (wm *WarehouseManager) isFruitID(id int64) bool {
 	return wm.fruitIDSet[id]
 }
 
+// isShopSeedID 检查种子是否在种子商店出售 (不在商店的视为活动种子)
+func (wm *WarehouseManager) isShopSeedID(id int64) bool {
+	wm.mu.RLock()
+	defer wm.mu.RUnlock()
+	return wm.shopSeedIDSet[id]
+}
+
 // GetBag 获取背包信息
 func (wm *WarehouseManager) GetBag() (*itempb.BagReply, error) {
 	req := &itempb.BagRequest{}
 	resp := &itempb.BagReply{}
 
-	err := network.Net.SendProtoMessage("gamepb.itempb.ItemService", "Bag", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.itempb.ItemService", "Bag", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -100,7 +113,7 @@ func (wm *WarehouseManager) SellItems(items []*corepb.Item) (*itempb.SellReply,
 	}
 	resp := &itempb.SellReply{}
 
-	err := network.Net.SendProtoMessage("gamepb.itempb.ItemService", "Sell", req, resp, 10*time.Second)
+	err := sendGameRequest("gamepb.itempb.ItemService", "Sell", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -187,6 +200,11 @@ func (wm *WarehouseManager) SellAllFruits() {
 	wm.isChecking = true
 	defer func() { wm.isChecking = false }()
 
+	// 作息: 暂停时不出售
+	if Schedule.ModeFor(ModuleWarehouse) == ModePaused {
+		return
+	}
+
 	// 获取背包
 	bagReply, err := wm.GetBag()
 	if err != nil {
@@ -196,6 +214,7 @@ func (wm *WarehouseManager) SellAllFruits() {
 
 	items := wm.getBagItems(bagReply)
 	utils.Log("仓库系统", fmt.Sprintf("背包共有 %d 个物品", len(items)))
+	Mutation.SyncBag(items)
 
 	if len(items) == 0 {
 		return
@@ -214,9 +233,17 @@ func (wm *WarehouseManager) SellAllFruits() {
 	var fruitNames []string
 
 	for _, fruit := range fruits {
-		// 直接使用从背包获取的原始物品对象
-		toSell = append(toSell, fruit.Item)
-		fruitNames = append(fruitNames, fmt.Sprintf("%s x%d", fruit.Name, fruit.Count))
+		// 直接使用从背包获取的原始物品对象 (变异作物的果实保留不卖)
+		item := Mutation.SellableItem(fruit.Item)
+		if item == nil {
+			continue
+		}
+		toSell = append(toSell, item)
+		fruitNames = append(fruitNames, fmt.Sprintf("%s x%d", fruit.Name, item.Count))
+	}
+
+	if len(toSell) == 0 {
+		return
 	}
 
 	utils.Log("仓库系统", fmt.Sprintf("准备出售 %d 个物品: %v", len(toSell), fruitNames))
@@ -324,7 +351,7 @@ func (wm *WarehouseManager) StartSellLoop() {
 	go func() {
 		for wm.loopRunning {
 			// 等待间隔时间
-			time.Sleep(SellCheckInterval)
+			time.Sleep(Schedule.Interval(ModuleWarehouse, SellCheckInterval))
 
 			if !wm.loopRunning {
 				break
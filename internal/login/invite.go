@@ -10,7 +10,7 @@ import (
 	"time"
 
 	"gofarm/internal/config"
-	"gofarm/internal/network"
+	"gofarm/internal/game"
 	"gofarm/proto/gamepb/userpb"
 	"gofarm/internal/utils"
 )
@@ -112,7 +112,7 @@ func SendReportArkClick(sharerID int64, sharerOpenID string, shareSource string)
 	}
 	resp := &userpb.ReportArkClickReply{}
 
-	err := network.Net.SendProtoMessage("gamepb.userpb.UserService", "ReportArkClick", req, resp, 10*time.Second)
+	err := game.SendGameRequest("gamepb.userpb.UserService", "ReportArkClick", req, resp, 10*time.Second)
 	return resp, err
 }
 
@@ -1,6 +1,7 @@
 package network
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -121,6 +122,30 @@ type Response struct {
 	Err  error
 }
 
+// ServerError 服务器返回的业务错误
+type ServerError struct {
+	Service string
+	Method  string
+	Code    int64
+	Message string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("%s.%s 错误: code=%d %s", e.Service, e.Method, e.Code, e.Message)
+}
+
+// ErrorCode 获取错误中的服务器错误码 (非服务器错误返回 -1, 无错误返回 0)
+func ErrorCode(err error) int64 {
+	if err == nil {
+		return 0
+	}
+	var serverErr *ServerError
+	if errors.As(err, &serverErr) {
+		return serverErr.Code
+	}
+	return -1
+}
+
 // 全局网络实例
 var Net *NetworkManager
 
@@ -330,9 +355,12 @@ func (nm *NetworkManager) handleMessage(data []byte) {
 				Meta: meta,
 			}
 			if meta.ErrorCode != 0 {
-				resp.Err = fmt.Errorf("%s.%s 错误: code=%d %s",
-					meta.ServiceName, meta.MethodName,
-					meta.ErrorCode, meta.ErrorMessage)
+				resp.Err = &ServerError{
+					Service: meta.ServiceName,
+					Method:  meta.MethodName,
+					Code:    meta.ErrorCode,
+					Message: meta.ErrorMessage,
+				}
 			}
 			callback <- resp
 		}
@@ -384,6 +412,18 @@ func (nm *NetworkManager) handleNotify(msg *gatepb.Message) {
 		nm.events.Emit("taskInfoNotify", eventMsg.Body)
 		return
 	}
+
+	// 收到好友申请
+	if contains(msgType, "FriendApplicationReceivedNotify") {
+		nm.events.Emit("friendApplicationReceived", eventMsg.Body)
+		return
+	}
+
+	// 好友添加成功
+	if contains(msgType, "FriendAddedNotify") {
+		nm.events.Emit("friendAdded", eventMsg.Body)
+		return
+	}
 }
 
 func contains(s, substr string) bool {
@@ -16,6 +16,8 @@ type StatusData struct {
 	Level    int
 	Gold     int64
 	Exp      int64
+	Alert    string // 提醒信息 (如临期物品)
+	Schedule string // 当前作息 (未配置作息时为空)
 	mu       sync.RWMutex
 }
 
@@ -101,6 +103,8 @@ func renderStatusBar() {
 	level := statusData.Level
 	gold := statusData.Gold
 	exp := statusData.Exp
+	alert := statusData.Alert
+	schedule := statusData.Schedule
 	statusData.mu.RUnlock()
 
 	// 构建状态行
@@ -131,14 +135,20 @@ func renderStatusBar() {
 		}
 	}
 
-	// 第一行：平台 | 昵称 | 等级 | 金币 | 经验
+	// 第一行：平台 | 昵称 | 等级 | 金币 | 经验 | 作息
 	line1 := fmt.Sprintf("%s | %s | %s | %s", platformStr, nameStr, levelStr, goldStr)
 	if expStr != "" {
 		line1 += " | " + expStr
 	}
+	if schedule != "" {
+		line1 += " | " + cyan + schedule + reset
+	}
 
-	// 第二行：固定提醒
+	// 第二行：固定提醒 + 提醒信息
 	line2 := dim + freeProjectTip + reset
+	if alert != "" {
+		line2 += " " + yellow + alert + reset
+	}
 
 	// 第三行：分隔线
 	width := 80
@@ -177,6 +187,14 @@ func updateStatus(data map[string]interface{}) {
 		statusData.Exp = exp
 		changed = true
 	}
+	if alert, ok := data["alert"].(string); ok && statusData.Alert != alert {
+		statusData.Alert = alert
+		changed = true
+	}
+	if schedule, ok := data["schedule"].(string); ok && statusData.Schedule != schedule {
+		statusData.Schedule = schedule
+		changed = true
+	}
 	statusData.mu.Unlock()
 
 	if changed && statusEnabled {
@@ -212,6 +230,16 @@ func UpdateStatusLevel(level int, exp int64) {
 	})
 }
 
+// UpdateStatusAlert 更新提醒信息 (空字符串表示清除)
+func UpdateStatusAlert(alert string) {
+	updateStatus(map[string]interface{}{"alert": alert})
+}
+
+// UpdateStatusSchedule 更新当前作息
+func UpdateStatusSchedule(schedule string) {
+	updateStatus(map[string]interface{}{"schedule": schedule})
+}
+
 // GetStatusData 获取状态数据
 func GetStatusData() (string, int, int64, int64) {
 	statusData.mu.RLock()
@@ -57,6 +57,14 @@ func GetServerTimeSec() int64 {
 	return (serverTimeMs + elapsed) / 1000
 }
 
+// GetServerTimeMs 获取当前推算的服务器时间(毫秒)
+func GetServerTimeMs() int64 {
+	if serverTimeMs == 0 {
+		return time.Now().UnixMilli()
+	}
+	return serverTimeMs + time.Now().UnixMilli() - localTimeAtSync
+}
+
 // SyncServerTime 同步服务器时间
 func SyncServerTime(ms int64) {
 	serverTimeMs = ms
@@ -75,6 +83,18 @@ func ToTimeSec(val interface{}) int64 {
 	return n
 }
 
+// ToTimeMs 将时间戳归一化为毫秒级
+func ToTimeMs(val interface{}) int64 {
+	n := ToNum(val)
+	if n <= 0 {
+		return 0
+	}
+	if n > 1e12 {
+		return n
+	}
+	return n * 1000
+}
+
 // Log 输出日志
 func Log(tag, msg string) {
 	fmt.Printf("[%s] [%s] %s\n", Now(), tag, msg)
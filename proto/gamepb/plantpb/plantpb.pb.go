@@ -7,6 +7,7 @@
 package plantpb
 
 import (
+	corepb "gofarm/proto/corepb"
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
 	reflect "reflect"
@@ -956,11 +957,11 @@ func (x *HarvestRequest) GetIsAll() bool {
 }
 
 type HarvestReply struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	Land  []*LandInfo            `protobuf:"bytes,1,rep,name=land,proto3" json:"land,omitempty"`
-	// repeated Item items = 2;     // corepb.Item
-	// repeated Item lost_items = 3;
-	OperationLimits []*OperationLimit `protobuf:"bytes,4,rep,name=operation_limits,json=operationLimits,proto3" json:"operation_limits,omitempty"`
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Land            []*LandInfo            `protobuf:"bytes,1,rep,name=land,proto3" json:"land,omitempty"`
+	Items           []*corepb.Item         `protobuf:"bytes,2,rep,name=items,proto3" json:"items,omitempty"`                          // 收获/偷到的物品
+	LostItems       []*corepb.Item         `protobuf:"bytes,3,rep,name=lost_items,json=lostItems,proto3" json:"lost_items,omitempty"` // 被偷走的物品
+	OperationLimits []*OperationLimit      `protobuf:"bytes,4,rep,name=operation_limits,json=operationLimits,proto3" json:"operation_limits,omitempty"`
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
@@ -1002,6 +1003,20 @@ func (x *HarvestReply) GetLand() []*LandInfo {
 	return nil
 }
 
+func (x *HarvestReply) GetItems() []*corepb.Item {
+	if x != nil {
+		return x.Items
+	}
+	return nil
+}
+
+func (x *HarvestReply) GetLostItems() []*corepb.Item {
+	if x != nil {
+		return x.LostItems
+	}
+	return nil
+}
+
 func (x *HarvestReply) GetOperationLimits() []*OperationLimit {
 	if x != nil {
 		return x.OperationLimits
@@ -2027,7 +2042,7 @@ var File_plantpb_proto protoreflect.FileDescriptor
 
 const file_plantpb_proto_rawDesc = "" +
 	"\n" +
-	"\rplantpb.proto\x12\x0egamepb.plantpb\"\x91\x06\n" +
+	"\rplantpb.proto\x12\x0egamepb.plantpb\x1a\fcorepb.proto\"\x91\x06\n" +
 	"\bLandInfo\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1a\n" +
 	"\bunlocked\x18\x02 \x01(\bR\bunlocked\x12\x14\n" +
@@ -2122,9 +2137,12 @@ const file_plantpb_proto_rawDesc = "" +
 	"\x0eHarvestRequest\x12\x19\n" +
 	"\bland_ids\x18\x01 \x03(\x03R\alandIds\x12\x19\n" +
 	"\bhost_gid\x18\x02 \x01(\x03R\ahostGid\x12\x15\n" +
-	"\x06is_all\x18\x03 \x01(\bR\x05isAll\"\x87\x01\n" +
+	"\x06is_all\x18\x03 \x01(\bR\x05isAll\"\xd8\x01\n" +
 	"\fHarvestReply\x12,\n" +
-	"\x04land\x18\x01 \x03(\v2\x18.gamepb.plantpb.LandInfoR\x04land\x12I\n" +
+	"\x04land\x18\x01 \x03(\v2\x18.gamepb.plantpb.LandInfoR\x04land\x12\"\n" +
+	"\x05items\x18\x02 \x03(\v2\f.corepb.ItemR\x05items\x12+\n" +
+	"\n" +
+	"lost_items\x18\x03 \x03(\v2\f.corepb.ItemR\tlostItems\x12I\n" +
 	"\x10operation_limits\x18\x04 \x03(\v2\x1e.gamepb.plantpb.OperationLimitR\x0foperationLimits\"H\n" +
 	"\x10WaterLandRequest\x12\x19\n" +
 	"\bland_ids\x18\x01 \x03(\x03R\alandIds\x12\x19\n" +
@@ -2248,6 +2266,7 @@ var file_plantpb_proto_goTypes = []any{
 	(*LandInfo_Buff)(nil),        // 30: gamepb.plantpb.LandInfo.Buff
 	nil,                          // 31: gamepb.plantpb.PlantPhaseInfo.FertsUsedEntry
 	nil,                          // 32: gamepb.plantpb.PlantRequest.LandAndSeedEntry
+	(*corepb.Item)(nil),          // 33: corepb.Item
 }
 var file_plantpb_proto_depIdxs = []int32{
 	2,  // 0: gamepb.plantpb.LandInfo.unlock_condition:type_name -> gamepb.plantpb.LandUnlockCondition
@@ -2260,31 +2279,33 @@ var file_plantpb_proto_depIdxs = []int32{
 	1,  // 7: gamepb.plantpb.AllLandsReply.lands:type_name -> gamepb.plantpb.LandInfo
 	7,  // 8: gamepb.plantpb.AllLandsReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
 	1,  // 9: gamepb.plantpb.HarvestReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 10: gamepb.plantpb.HarvestReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	1,  // 11: gamepb.plantpb.WaterLandReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 12: gamepb.plantpb.WaterLandReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	1,  // 13: gamepb.plantpb.WeedOutReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 14: gamepb.plantpb.WeedOutReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	1,  // 15: gamepb.plantpb.InsecticideReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 16: gamepb.plantpb.InsecticideReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	32, // 17: gamepb.plantpb.PlantRequest.land_and_seed:type_name -> gamepb.plantpb.PlantRequest.LandAndSeedEntry
-	18, // 18: gamepb.plantpb.PlantRequest.items:type_name -> gamepb.plantpb.PlantItem
-	1,  // 19: gamepb.plantpb.PlantReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 20: gamepb.plantpb.PlantReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	1,  // 21: gamepb.plantpb.RemovePlantReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 22: gamepb.plantpb.RemovePlantReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	1,  // 23: gamepb.plantpb.FertilizeReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 24: gamepb.plantpb.FertilizeReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	1,  // 25: gamepb.plantpb.PutInsectsReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 26: gamepb.plantpb.PutInsectsReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	1,  // 27: gamepb.plantpb.PutWeedsReply.land:type_name -> gamepb.plantpb.LandInfo
-	7,  // 28: gamepb.plantpb.PutWeedsReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
-	1,  // 29: gamepb.plantpb.LandsNotify.lands:type_name -> gamepb.plantpb.LandInfo
-	30, // [30:30] is the sub-list for method output_type
-	30, // [30:30] is the sub-list for method input_type
-	30, // [30:30] is the sub-list for extension type_name
-	30, // [30:30] is the sub-list for extension extendee
-	0,  // [0:30] is the sub-list for field type_name
+	33, // 10: gamepb.plantpb.HarvestReply.items:type_name -> corepb.Item
+	33, // 11: gamepb.plantpb.HarvestReply.lost_items:type_name -> corepb.Item
+	7,  // 12: gamepb.plantpb.HarvestReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	1,  // 13: gamepb.plantpb.WaterLandReply.land:type_name -> gamepb.plantpb.LandInfo
+	7,  // 14: gamepb.plantpb.WaterLandReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	1,  // 15: gamepb.plantpb.WeedOutReply.land:type_name -> gamepb.plantpb.LandInfo
+	7,  // 16: gamepb.plantpb.WeedOutReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	1,  // 17: gamepb.plantpb.InsecticideReply.land:type_name -> gamepb.plantpb.LandInfo
+	7,  // 18: gamepb.plantpb.InsecticideReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	32, // 19: gamepb.plantpb.PlantRequest.land_and_seed:type_name -> gamepb.plantpb.PlantRequest.LandAndSeedEntry
+	18, // 20: gamepb.plantpb.PlantRequest.items:type_name -> gamepb.plantpb.PlantItem
+	1,  // 21: gamepb.plantpb.PlantReply.land:type_name -> gamepb.plantpb.LandInfo
+	7,  // 22: gamepb.plantpb.PlantReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	1,  // 23: gamepb.plantpb.RemovePlantReply.land:type_name -> gamepb.plantpb.LandInfo
+	7,  // 24: gamepb.plantpb.RemovePlantReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	1,  // 25: gamepb.plantpb.FertilizeReply.land:type_name -> gamepb.plantpb.LandInfo
+	7,  // 26: gamepb.plantpb.FertilizeReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	1,  // 27: gamepb.plantpb.PutInsectsReply.land:type_name -> gamepb.plantpb.LandInfo
+	7,  // 28: gamepb.plantpb.PutInsectsReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	1,  // 29: gamepb.plantpb.PutWeedsReply.land:type_name -> gamepb.plantpb.LandInfo
+	7,  // 30: gamepb.plantpb.PutWeedsReply.operation_limits:type_name -> gamepb.plantpb.OperationLimit
+	1,  // 31: gamepb.plantpb.LandsNotify.lands:type_name -> gamepb.plantpb.LandInfo
+	32, // [32:32] is the sub-list for method output_type
+	32, // [32:32] is the sub-list for method input_type
+	32, // [32:32] is the sub-list for extension type_name
+	32, // [32:32] is the sub-list for extension extendee
+	0,  // [0:32] is the sub-list for field type_name
 }
 
 func init() { file_plantpb_proto_init() }